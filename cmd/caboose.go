@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"log"
+	"os"
+
+	"github.com/gofrs/uuid/v5"
 
 	"github.com/element84/swoop-go/pkg/caboose/argo"
 
 	"github.com/element84/swoop-go/pkg/cmdutil"
 	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/context"
 	"github.com/element84/swoop-go/pkg/db"
 	"github.com/element84/swoop-go/pkg/s3"
 	"github.com/spf13/cobra"
@@ -22,12 +26,11 @@ func mkCabooseCmd() *cobra.Command {
 		Use:   "caboose",
 		Short: "swoop-caboose commands for state updates",
 	}
-	s3Driver := &s3.S3Driver{}
-	s3Driver.AddFlags(cmd.PersistentFlags())
-	conf := &config.ConfigFile{}
-	conf.AddFlags(cmd.PersistentFlags())
 
 	cmd.AddCommand(func() *cobra.Command {
+		// not persistent flags, as the dead-letter commands need their own
+		s3Driver := &s3.S3Driver{}
+		conf := &config.ConfigFile{}
 		configFlags := genericclioptions.NewConfigFlags(true)
 		cmd := &cobra.Command{
 			Use:   "argo",
@@ -51,6 +54,77 @@ func mkCabooseCmd() *cobra.Command {
 				}
 			},
 		}
+		s3Driver.AddFlags(cmd.Flags())
+		conf.AddFlags(cmd.Flags())
+		configFlags.AddFlags(cmd.Flags())
+		return cmd
+	}())
+
+	cmd.AddCommand(mkDeadLetterCmd())
+
+	return cmd
+}
+
+func mkDeadLetterCmd() *cobra.Command {
+	s3Driver := &s3.S3Driver{}
+	deadLetters := &argo.DeadLetters{
+		S3Driver: s3Driver,
+		DbConfig: &db.PoolConfig{},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "dead-letter",
+		Short: "Manage workflow completions the caboose failed to process",
+	}
+	s3Driver.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List dead-lettered workflow completions",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.NewApplicationContext("swoop-caboose")
+			err := deadLetters.List(ctx, os.Stdout)
+			if err != nil {
+				log.Fatalf("Error listing dead letters: %s", err)
+			}
+		},
+	})
+
+	cmd.AddCommand(func() *cobra.Command {
+		conf := &config.ConfigFile{}
+		configFlags := genericclioptions.NewConfigFlags(true)
+		cmd := &cobra.Command{
+			Use:   "requeue WORKFLOW_UUID...",
+			Short: "Requeue dead-lettered workflow completions for processing",
+			Args:  cobra.MinimumNArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				workflowUuids := make([]uuid.UUID, 0, len(args))
+				for _, arg := range args {
+					workflowUuid, err := uuid.FromString(arg)
+					if err != nil {
+						log.Fatalf("Invalid workflow uuid '%s': %s", arg, err)
+					}
+					workflowUuids = append(workflowUuids, workflowUuid)
+				}
+
+				// completions of deleted workflows are processed here,
+				// so we need everything the caboose does
+				sc, err := conf.Parse()
+				if err != nil {
+					log.Fatal(err)
+				}
+				deadLetters.SwoopConfig = sc
+				deadLetters.K8sConfigFlags = configFlags
+
+				ctx := context.NewApplicationContext("swoop-caboose")
+				err = deadLetters.Requeue(ctx, workflowUuids)
+				if err != nil {
+					log.Fatalf("Error requeueing dead letters: %s", err)
+				}
+			},
+		}
+		conf.AddFlags(cmd.Flags())
 		configFlags.AddFlags(cmd.Flags())
 		return cmd
 	}())
@@ -2,6 +2,8 @@ package argo
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -13,16 +15,18 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	wfclientset "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned"
-	commonutil "github.com/argoproj/argo-workflows/v3/util"
 	"github.com/argoproj/argo-workflows/v3/workflow/common"
 	"github.com/argoproj/argo-workflows/v3/workflow/util"
 	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/element84/swoop-go/pkg/caboose"
@@ -33,15 +37,6 @@ import (
 	"github.com/element84/swoop-go/pkg/utils"
 )
 
-const (
-	// TODO: make these config parameters
-	workflowResyncPeriod = 20 * time.Minute
-	maxWorkers           = 4
-	minBackoff           = 2 * time.Second
-	maxBackoff           = 300 * time.Second
-	instanceId           = ""
-)
-
 func indexFn(obj any) ([]string, error) {
 	un, ok := obj.(*unstructured.Unstructured)
 	if !ok {
@@ -66,6 +61,18 @@ func statusFromPhase(phase string) (states.WorkflowState, error) {
 	return states.ParseWorkflowState(phase)
 }
 
+var swoopWorkflowRequirement = func() labels.Requirement {
+	r, err := labels.NewRequirement(config.SwoopWorkflowIdLabelName, selection.Exists, nil)
+	if err != nil {
+		panic(err)
+	}
+	return *r
+}()
+
+// errNotSwoopWorkflow is returned for unlabeled workflows that turn out
+// not to be swoop workflows, which we skip quietly
+var errNotSwoopWorkflow = errors.New("not a swoop workflow")
+
 type wfEventType int
 
 const (
@@ -83,6 +90,7 @@ type workflowEvent struct {
 type argoCabooseRunner struct {
 	s3          *s3.SwoopS3
 	callbackMap caboose.CallbackMap
+	settings    *config.Caboose
 	ctx         context.Context
 	db          *pgxpool.Pool
 	wfClientSet wfclientset.Interface
@@ -117,6 +125,7 @@ func (acr *argoCabooseRunner) newWorkflowProperties(raw any) (*caboose.WorkflowP
 
 	p := &caboose.WorkflowProperties{
 		Uuid: uuid.FromStringOrNil(un.GetName()),
+		Name: labels[config.SwoopWorkflowIdLabelName],
 	}
 
 	phase := labels[common.LabelKeyPhase]
@@ -135,12 +144,19 @@ func (acr *argoCabooseRunner) newWorkflowProperties(raw any) (*caboose.WorkflowP
 	p.ErrorMsg, _, _ = unstructured.NestedString(statusMap, "message")
 
 	if p.Uuid.IsNil() {
+		if p.Name == "" {
+			return nil, errNotSwoopWorkflow
+		}
 		return nil, fmt.Errorf("unknown workflow: %v", raw)
 	}
 
-	// TODO: need a label on workflows to prevent this lookup
+	// only workflows submitted before the workflow id label
+	// was added should need this lookup
 	err = p.LookupName(acr.ctx, acr.db)
-	if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+		// an unlabeled workflow swoop doesn't know is not ours
+		return nil, errNotSwoopWorkflow
+	} else if err != nil {
 		return nil, fmt.Errorf(
 			"failed to lookup workflow name for uuid '%s': %s",
 			p.Uuid,
@@ -151,80 +167,69 @@ func (acr *argoCabooseRunner) newWorkflowProperties(raw any) (*caboose.WorkflowP
 	return p, nil
 }
 
-// TODO: how to restart failed workers?
-//
-//	Panic handler?
-//	wait.Until?
-func (acr *argoCabooseRunner) worker(id int) {
-	log.Printf("starting worker %d", id)
-	acr.wg.Add(1)
-	defer acr.wg.Done()
-
-	end := func() {
-		log.Printf("stopping worker %d", id)
-	}
-
-	for {
-		// first select is to give priority to ctx.Done
-		select {
-		case <-acr.ctx.Done():
-			end()
-			return
-		default:
-		}
-
-		select {
-		case wf := <-acr.wfChan:
-			acr.process(wf)
-		case <-acr.ctx.Done():
-			end()
-			return
-		}
-
-	}
-}
-
-func (acr *argoCabooseRunner) StartWorkers() {
-	for i := 0; i < maxWorkers; i++ {
-		go acr.worker(i)
-	}
-}
-
+// process handles the event within the event timeout
 func (acr *argoCabooseRunner) process(wf *workflowEvent) {
+	ctx, cancel := context.WithTimeout(acr.ctx, acr.settings.EventTimeout)
+	defer cancel()
+
 	switch wf.eventType {
 	case started:
-		acr.wfStart(wf)
+		acr.wfStart(ctx, wf)
 	case completed:
-		err := acr.wfDone(wf)
+		err := acr.wfDone(ctx, wf)
 		if err != nil {
-			log.Printf(
-				"error encountered processing '%s': %s",
-				wf.properties.Uuid,
-				err,
-			)
-			go acr.backoff(wf)
+			acr.retry(wf, err)
 		}
 	}
 }
 
-func (acr *argoCabooseRunner) backoff(wf *workflowEvent) {
-	backoffSecs := time.Duration(utils.IntPow(2, wf.retries)) * minBackoff
-	if backoffSecs > maxBackoff {
-		backoffSecs = maxBackoff
+// retry logs the error and schedules a retry of the event, if it can be
+// retried, or dead-letters it once it has exhausted its retries
+func (acr *argoCabooseRunner) retry(wf *workflowEvent, err error) {
+	if wf.eventType != completed {
+		return
+	}
+
+	log.Printf(
+		"error encountered processing '%s': %s",
+		wf.properties.Uuid,
+		err,
+	)
+
+	if wf.retries >= acr.settings.MaxRetries {
+		log.Printf(
+			"retries exhausted processing '%s', dead-lettering it",
+			wf.properties.Uuid,
+		)
+		go acr.deadLetter(wf, err)
+		return
 	}
 
+	go acr.backoff(wf)
+}
+
+// backoffFor is the delay before the given retry
+func (acr *argoCabooseRunner) backoffFor(retries int) time.Duration {
+	backoffSecs := time.Duration(utils.IntPow(2, min(retries, 16))) * acr.settings.MinBackoff
+	return min(backoffSecs, acr.settings.MaxBackoff)
+}
+
+func (acr *argoCabooseRunner) backoff(wf *workflowEvent) {
 	select {
 	case <-acr.ctx.Done():
 		return
-	case <-time.After(backoffSecs):
+	case <-time.After(acr.backoffFor(wf.retries)):
 	}
 
 	wf.retries++
-	acr.wfChan <- wf
+	select {
+	case <-acr.ctx.Done():
+	case acr.wfChan <- wf:
+	}
 }
 
-func (acr *argoCabooseRunner) wfStart(wf *workflowEvent) error {
-	err := wf.properties.ToStartEvent().Insert(acr.ctx, acr.db)
+func (acr *argoCabooseRunner) wfStart(ctx context.Context, wf *workflowEvent) error {
+	err := wf.properties.ToStartEvent().Insert(ctx, acr.db)
 	if err != nil {
 		return err
 	}
@@ -235,14 +240,17 @@ func (acr *argoCabooseRunner) wfStart(wf *workflowEvent) error {
 	return nil
 }
 
-func (acr *argoCabooseRunner) wfDone(wf *workflowEvent) error {
-	tx, err := acr.db.Begin(acr.ctx)
+func (acr *argoCabooseRunner) wfDone(ctx context.Context, wf *workflowEvent) error {
+	tx, err := acr.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	// not ctx, so we can still roll back after the event deadline
 	defer tx.Rollback(acr.ctx)
 
-	err = wf.properties.ToStartEvent().Insert(acr.ctx, acr.db)
+	// all writes go through the transaction, so a failure part way leaves
+	// nothing behind, and the retry starts afresh
+	err = wf.properties.ToStartEvent().Insert(ctx, tx)
 	if err != nil {
 		return err
 	}
@@ -251,7 +259,7 @@ func (acr *argoCabooseRunner) wfDone(wf *workflowEvent) error {
 		wf.properties.Uuid,
 	)
 
-	err = wf.properties.ToEndEvent().Insert(acr.ctx, acr.db)
+	err = wf.properties.ToEndEvent().Insert(ctx, tx)
 	if err != nil {
 		return err
 	}
@@ -260,7 +268,7 @@ func (acr *argoCabooseRunner) wfDone(wf *workflowEvent) error {
 		wf.properties.Uuid,
 	)
 
-	err = acr.s3.PutWorkflowResource(acr.ctx, wf.properties.Uuid, wf.wf)
+	err = acr.s3.PutWorkflowResource(ctx, wf.properties.Uuid, wf.wf)
 	if err != nil {
 		return err
 	}
@@ -278,37 +286,30 @@ func (acr *argoCabooseRunner) wfDone(wf *workflowEvent) error {
 	}
 
 	err = caboose.NewCallbackExecutor(
-		acr.ctx,
+		ctx,
 		acr.s3,
-		acr.db,
+		tx,
 	).ProcessCallbacks(callbacks, wf.properties)
 	if err != nil {
 		return err
 	}
 
-	// TODO: do we have a possible race here? If we delete the workflow before
-	// argo has finished with cleanup or other post-workflow tasks, will it
-	// lose the state it needs to finish them? How can we know the workflow is
-	// good to clean up?
-	//
-	// Maybe we apply a label, and use another listener to delete workflows
-	// with said label (and perhaps another argo one)?
-	//
-	// Looks like argo is looking for `common.IsDone(un)`
-	err = acr.deleteWorkflow(wf)
+	err = tx.Commit(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = tx.Commit(acr.ctx)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// The workflow is only marked processed once committed, as the label
+	// hides it from us. If marking it fails the event is retried, which
+	// is safe as the writes above are idempotent. We don't delete the
+	// workflow here, as argo may not be done with it; the reaper deletes
+	// it later, if deletion is enabled.
+	return acr.markProcessed(ctx, wf)
 }
 
-func (acr *argoCabooseRunner) deleteWorkflow(wf *workflowEvent) error {
+// markProcessed labels the workflow as processed so it is not processed
+// again, and so the reaper can find it
+func (acr *argoCabooseRunner) markProcessed(ctx context.Context, wf *workflowEvent) error {
 	key, err := cache.MetaNamespaceKeyFunc(wf.wf)
 	if err != nil {
 		return err
@@ -316,12 +317,26 @@ func (acr *argoCabooseRunner) deleteWorkflow(wf *workflowEvent) error {
 
 	namespace, name, _ := cache.SplitMetaNamespaceKey(key)
 
-	err = acr.wfClientSet.ArgoprojV1alpha1().Workflows(namespace).Delete(
-		acr.ctx,
-		name,
-		metav1.DeleteOptions{
-			PropagationPolicy: commonutil.GetDeletePropagation(),
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels": map[string]string{
+				ProcessedLabelName: "true",
+			},
+			"annotations": map[string]string{
+				ProcessedAtAnnotationName: time.Now().UTC().Format(time.RFC3339),
+			},
 		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = acr.wfClientSet.ArgoprojV1alpha1().Workflows(namespace).Patch(
+		ctx,
+		name,
+		types.MergePatchType,
+		patch,
+		metav1.PatchOptions{},
 	)
 	if err != nil {
 		if apierr.IsNotFound(err) {
@@ -330,19 +345,28 @@ func (acr *argoCabooseRunner) deleteWorkflow(wf *workflowEvent) error {
 			return err
 		}
 	} else {
-		log.Printf("Successfully requested to delete workflow '%s'", key)
+		log.Printf("Marked workflow as processed '%s'", key)
 	}
 
 	return nil
 }
 
+// isSwoopWorkflow is true if the workflow has the swoop workflow id label,
+// or could be an unlabeled swoop workflow if legacy lookups are enabled
+func (acr *argoCabooseRunner) isSwoopWorkflow(un *unstructured.Unstructured) bool {
+	_, ok := un.GetLabels()[config.SwoopWorkflowIdLabelName]
+	return ok || acr.settings.LegacyNameLookup
+}
+
 func (acr *argoCabooseRunner) addWorkflowInformerHandlers(
 	wfInformer cache.SharedIndexInformer,
 ) {
 	handle := func(eventType wfEventType) func(interface{}) {
 		return func(obj interface{}) {
 			wf, err := acr.newWorkflowEvent(eventType, obj)
-			if err != nil {
+			if errors.Is(err, errNotSwoopWorkflow) {
+				return
+			} else if err != nil {
 				log.Println(err)
 				return
 			}
@@ -355,7 +379,7 @@ func (acr *argoCabooseRunner) addWorkflowInformerHandlers(
 		cache.FilteringResourceEventHandler{
 			FilterFunc: func(obj interface{}) bool {
 				un, ok := obj.(*unstructured.Unstructured)
-				return ok && un.GetLabels()[common.LabelKeyPhase] == "Running"
+				return ok && acr.isSwoopWorkflow(un) && un.GetLabels()[common.LabelKeyPhase] == "Running"
 			},
 			Handler: cache.ResourceEventHandlerFuncs{
 				AddFunc: handle(started),
@@ -367,7 +391,11 @@ func (acr *argoCabooseRunner) addWorkflowInformerHandlers(
 		cache.FilteringResourceEventHandler{
 			FilterFunc: func(obj interface{}) bool {
 				un, ok := obj.(*unstructured.Unstructured)
-				return ok && un.GetLabels()[common.LabelKeyCompleted] == "true"
+				return ok &&
+					acr.isSwoopWorkflow(un) &&
+					un.GetLabels()[common.LabelKeyCompleted] == "true" &&
+					!isProcessed(un) &&
+					!isDeadLettered(un)
 			},
 			Handler: cache.ResourceEventHandlerFuncs{
 				AddFunc: handle(completed),
@@ -411,6 +439,7 @@ func (c *ArgoCaboose) newArgoCabooseRunner(ctx context.Context) (*argoCabooseRun
 	return &argoCabooseRunner{
 		s3:          s3.NewSwoopS3(s3.NewJsonClient(c.S3Driver)),
 		callbackMap: caboose.MapConfigCallbacks(c.SwoopConfig),
+		settings:    c.SwoopConfig.Caboose,
 		ctx:         ctx,
 		db:          db,
 		wfClientSet: wfClientSet,
@@ -426,7 +455,7 @@ func (c *ArgoCaboose) Run(ctx context.Context, cancel context.CancelFunc) error
 		return err
 	}
 
-	acr.StartWorkers()
+	acr.startWorkers()
 
 	namespace := ""
 	if c.K8sConfigFlags.Namespace != nil {
@@ -436,10 +465,14 @@ func (c *ArgoCaboose) Run(ctx context.Context, cancel context.CancelFunc) error
 	wfInformer := util.NewWorkflowInformer(
 		acr.dynIface,
 		namespace,
-		workflowResyncPeriod,
+		acr.settings.WorkflowResyncPeriod,
 		func(options *metav1.ListOptions) {
 			labelSelector := labels.NewSelector().
-				Add(util.InstanceIDRequirement(instanceId))
+				Add(util.InstanceIDRequirement(acr.settings.InstanceId))
+			if !acr.settings.LegacyNameLookup {
+				// we need not even watch workflows we will ignore
+				labelSelector = labelSelector.Add(swoopWorkflowRequirement)
+			}
 			options.LabelSelector = labelSelector.String()
 		},
 		cache.Indexers{
@@ -448,6 +481,15 @@ func (c *ArgoCaboose) Run(ctx context.Context, cancel context.CancelFunc) error
 	)
 
 	acr.addWorkflowInformerHandlers(wfInformer)
+
+	if acr.settings.WorkflowDeletion.Enabled {
+		reaper := newReaper(ctx, acr.settings, acr.wfClientSet, wfInformer.GetStore())
+		reaper.addInformerHandlers(wfInformer)
+		go reaper.Run()
+	} else {
+		log.Printf("workflow deletion disabled, processed workflows will be kept")
+	}
+
 	go wfInformer.Run(ctx.Done())
 
 	if !cache.WaitForCacheSync(
@@ -457,13 +499,9 @@ func (c *ArgoCaboose) Run(ctx context.Context, cancel context.CancelFunc) error
 		return fmt.Errorf("timed out waiting for cache to sync")
 	}
 
-	// TODO: what happens if a worker hangs? Workers should timeout?
-	// we wait on the workers, as they are waiting on ctx
+	// workers are supervised, so we run until we are stopped
+	<-ctx.Done()
 	acr.wg.Wait()
-	// if all the workers exit we cancel to make sure everything else stops
-	// TODO may want to consider a solution like https://github.com/jackc/puddle
-	//      to ensure dying workers are revived
-	cancel()
 	return nil
 }
 
@@ -0,0 +1,314 @@
+package argo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/cache"
+
+	wfclientset "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned"
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/db"
+	"github.com/element84/swoop-go/pkg/s3"
+)
+
+// DeadLetterLabelName marks workflows whose completion could not be
+// processed within the retry limit; they are ignored until requeued
+const DeadLetterLabelName = "swoop.element84.com/deadLetter"
+
+func isDeadLettered(un *unstructured.Unstructured) bool {
+	return un.GetLabels()[DeadLetterLabelName] == "true"
+}
+
+// deadLetterRecord is stored in object storage, and is the source of truth
+// for dead letters. It keeps the workflow resource, so the completion can
+// still be requeued if the workflow itself has since been deleted.
+type deadLetterRecord struct {
+	WorkflowUuid uuid.UUID `json:"workflowUuid"`
+	WorkflowName string    `json:"workflowName"`
+	Namespace    string    `json:"namespace"`
+	Error        string    `json:"error"`
+	Retries      int       `json:"retries"`
+	Time         time.Time `json:"time"`
+	Resource     any       `json:"resource"`
+}
+
+func getDeadLetter(
+	ctx context.Context,
+	swoopS3 *s3.SwoopS3,
+	workflowUuid uuid.UUID,
+) (*deadLetterRecord, error) {
+	j, err := swoopS3.GetDeadLetter(ctx, workflowUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+
+	dl := &deadLetterRecord{}
+	err = json.Unmarshal(b, dl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dead letter '%s': %s", workflowUuid, err)
+	}
+
+	return dl, nil
+}
+
+// listDeadLetters returns the dead letters in object storage, oldest first
+func listDeadLetters(ctx context.Context, swoopS3 *s3.SwoopS3) ([]*deadLetterRecord, error) {
+	workflowUuids, err := swoopS3.ListDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]*deadLetterRecord, 0, len(workflowUuids))
+	for _, workflowUuid := range workflowUuids {
+		dl, err := getDeadLetter(ctx, swoopS3, workflowUuid)
+		if s3.IsNotFound(err) {
+			// requeued since we listed it
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, dl)
+	}
+
+	sort.SliceStable(deadLetters, func(i, j int) bool {
+		return deadLetters[i].Time.Before(deadLetters[j].Time)
+	})
+	return deadLetters, nil
+}
+
+// patchLabels merge patches the workflow labels; a nil value removes the label
+func patchLabels(
+	ctx context.Context,
+	wfClientSet wfclientset.Interface,
+	namespace string,
+	name string,
+	labels map[string]any,
+) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels": labels,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = wfClientSet.ArgoprojV1alpha1().Workflows(namespace).Patch(
+		ctx,
+		name,
+		types.MergePatchType,
+		patch,
+		metav1.PatchOptions{},
+	)
+	return err
+}
+
+// deadLetter records the event as dead-lettered, retrying with backoff up
+// to the retry limit. If it still fails, the event goes back to being
+// retried from the start rather than being dropped.
+func (acr *argoCabooseRunner) deadLetter(wf *workflowEvent, cause error) {
+	for attempt := 0; ; attempt++ {
+		err := acr.recordDeadLetter(wf, cause)
+		if err == nil {
+			return
+		}
+
+		if attempt >= acr.settings.MaxRetries {
+			log.Printf(
+				"failed to dead-letter '%s', retrying the event instead: %s",
+				wf.properties.Uuid,
+				err,
+			)
+			wf.retries = 0
+			acr.backoff(wf)
+			return
+		}
+
+		backoff := acr.backoffFor(attempt)
+		log.Printf(
+			"failed to dead-letter '%s', will retry in %s: %s",
+			wf.properties.Uuid,
+			backoff,
+			err,
+		)
+
+		select {
+		case <-acr.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (acr *argoCabooseRunner) recordDeadLetter(wf *workflowEvent, cause error) error {
+	ctx, cancel := context.WithTimeout(acr.ctx, acr.settings.EventTimeout)
+	defer cancel()
+
+	key, err := cache.MetaNamespaceKeyFunc(wf.wf)
+	if err != nil {
+		return err
+	}
+
+	namespace, name, _ := cache.SplitMetaNamespaceKey(key)
+
+	err = acr.s3.PutDeadLetter(ctx, wf.properties.Uuid, &deadLetterRecord{
+		WorkflowUuid: wf.properties.Uuid,
+		WorkflowName: wf.properties.Name,
+		Namespace:    namespace,
+		Error:        cause.Error(),
+		Retries:      wf.retries,
+		Time:         time.Now().UTC(),
+		Resource:     wf.wf,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the label only stops us processing the workflow again; the record
+	// is kept even if the workflow is gone, so it can still be requeued
+	err = patchLabels(ctx, acr.wfClientSet, namespace, name, map[string]any{
+		DeadLetterLabelName: "true",
+	})
+	if apierr.IsNotFound(err) {
+		log.Printf("Workflow already deleted '%s'", key)
+	} else if err != nil {
+		return err
+	}
+
+	log.Printf("Dead-lettered workflow '%s' after %d retries", key, wf.retries)
+	return nil
+}
+
+// requeue removes the dead letter for the workflow and unlabels it, so a
+// running caboose sees it as newly completed and processes it again. If
+// the workflow no longer exists, we process the completion here from the
+// resource in the dead letter.
+func (acr *argoCabooseRunner) requeue(ctx context.Context, workflowUuid uuid.UUID) error {
+	dl, err := getDeadLetter(ctx, acr.s3, workflowUuid)
+	if s3.IsNotFound(err) {
+		return fmt.Errorf("workflow is not dead-lettered")
+	} else if err != nil {
+		return err
+	}
+
+	// The record is removed first, so a running caboose that fails the
+	// workflow again cannot have its new record removed. We put it back
+	// if we fail to requeue.
+	err = acr.s3.DeleteDeadLetter(ctx, workflowUuid)
+	if err != nil {
+		return err
+	}
+
+	err = patchLabels(ctx, acr.wfClientSet, dl.Namespace, workflowUuid.String(), map[string]any{
+		DeadLetterLabelName: nil,
+	})
+	if apierr.IsNotFound(err) {
+		err = acr.processDeadLetter(ctx, dl)
+	}
+
+	if err != nil {
+		_err := acr.s3.PutDeadLetter(ctx, workflowUuid, dl)
+		if _err != nil {
+			return fmt.Errorf("%s; while restoring the dead letter encountered another: %s", err, _err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// processDeadLetter processes the completion of a deleted workflow from
+// the resource recorded in its dead letter
+func (acr *argoCabooseRunner) processDeadLetter(ctx context.Context, dl *deadLetterRecord) error {
+	obj, ok := dl.Resource.(map[string]any)
+	if !ok {
+		return fmt.Errorf("dead letter has no workflow resource")
+	}
+
+	wf, err := acr.newWorkflowEvent(completed, &unstructured.Unstructured{Object: obj})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Workflow '%s' no longer exists, processing it from its dead letter", dl.WorkflowUuid)
+	return acr.wfDone(ctx, wf)
+}
+
+// DeadLetters lists and requeues dead-lettered workflow completions
+type DeadLetters struct {
+	S3Driver *s3.S3Driver
+	// the rest are only needed to requeue
+	SwoopConfig    *config.SwoopConfig
+	K8sConfigFlags *genericclioptions.ConfigFlags
+	DbConfig       *db.PoolConfig
+}
+
+func (d *DeadLetters) List(ctx context.Context, out io.Writer) error {
+	swoopS3 := s3.NewSwoopS3(s3.NewJsonClient(d.S3Driver))
+
+	deadLetters, err := listDeadLetters(ctx, swoopS3)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "UUID\tNAME\tNAMESPACE\tRETRIES\tTIME\tERROR")
+	for _, dl := range deadLetters {
+		// the full error is in the dead letter in object storage
+		msg, _, _ := strings.Cut(dl.Error, "\n")
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%d\t%s\t%s\n",
+			dl.WorkflowUuid,
+			dl.WorkflowName,
+			dl.Namespace,
+			dl.Retries,
+			dl.Time.UTC().Format(time.RFC3339),
+			msg,
+		)
+	}
+	return w.Flush()
+}
+
+func (d *DeadLetters) Requeue(ctx context.Context, workflowUuids []uuid.UUID) error {
+	acr, err := (&ArgoCaboose{
+		S3Driver:       d.S3Driver,
+		SwoopConfig:    d.SwoopConfig,
+		K8sConfigFlags: d.K8sConfigFlags,
+		DbConfig:       d.DbConfig,
+	}).newArgoCabooseRunner(ctx)
+	if err != nil {
+		return err
+	}
+	defer acr.db.Close()
+
+	for _, workflowUuid := range workflowUuids {
+		err := acr.requeue(ctx, workflowUuid)
+		if err != nil {
+			return fmt.Errorf("failed to requeue '%s': %s", workflowUuid, err)
+		}
+		log.Printf("Requeued workflow '%s'", workflowUuid)
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package argo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	wffake "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned/fake"
+	"github.com/argoproj/argo-workflows/v3/workflow/common"
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/element84/swoop-go/pkg/caboose"
+	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/s3"
+	testS3 "github.com/element84/swoop-go/pkg/utils/testing/s3"
+)
+
+func mkDeadLetterRunner(
+	t *testing.T,
+) (*argoCabooseRunner, *wffake.Clientset, *workflowEvent) {
+	// not ctx, as the bucket is removed after ctx is cancelled
+	t3 := testS3.NewTestingS3(t, "caboose-dead-letter-")
+	t3.SetupBucket(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	workflowUuid := uuid.Must(uuid.NewV4())
+	wf, un := mkWorkflow(t, workflowUuid.String(), map[string]string{
+		common.LabelKeyCompleted:        "true",
+		config.SwoopWorkflowIdLabelName: "mirror",
+	}, time.Time{}, time.Time{})
+	clientSet := wffake.NewSimpleClientset(wf)
+
+	settings := config.NewCaboose()
+	settings.MinBackoff = 10 * time.Millisecond
+	settings.MaxBackoff = 50 * time.Millisecond
+	settings.MaxRetries = 2
+
+	var wg sync.WaitGroup
+	acr := &argoCabooseRunner{
+		s3:          s3.NewSwoopS3(t3.JsonClient),
+		settings:    settings,
+		ctx:         ctx,
+		wfClientSet: clientSet,
+		wg:          &wg,
+		wfChan:      make(chan *workflowEvent, 1),
+	}
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	event := &workflowEvent{
+		eventType: completed,
+		wf:        un,
+		properties: &caboose.WorkflowProperties{
+			Uuid: workflowUuid,
+			Name: "mirror",
+		},
+	}
+
+	return acr, clientSet, event
+}
+
+func getWorkflow(t *testing.T, clientSet *wffake.Clientset, name string) *unstructured.Unstructured {
+	wf, err := clientSet.ArgoprojV1alpha1().Workflows(testNs).Get(
+		context.Background(),
+		name,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		t.Fatalf("failed to get workflow: %s", err)
+	}
+	un := &unstructured.Unstructured{}
+	un.SetLabels(wf.Labels)
+	un.SetAnnotations(wf.Annotations)
+	return un
+}
+
+func TestDeadLetterRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	acr, clientSet, event := mkDeadLetterRunner(t)
+	event.retries = 2
+	workflowUuid := event.properties.Uuid
+
+	err := acr.recordDeadLetter(event, fmt.Errorf("failed to write\nmore detail"))
+	if err != nil {
+		t.Fatalf("failed to dead-letter: %s", err)
+	}
+
+	un := getWorkflow(t, clientSet, workflowUuid.String())
+	if !isDeadLettered(un) {
+		t.Fatalf("expected workflow to be dead-lettered, got labels %v", un.GetLabels())
+	}
+
+	deadLetters, err := listDeadLetters(ctx, acr.s3)
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %s", err)
+	}
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(deadLetters))
+	}
+	dl := deadLetters[0]
+	if dl.WorkflowUuid != workflowUuid ||
+		dl.WorkflowName != "mirror" ||
+		dl.Namespace != testNs ||
+		dl.Error != "failed to write\nmore detail" ||
+		dl.Retries != 2 ||
+		time.Since(dl.Time) > time.Minute ||
+		dl.Resource == nil {
+		t.Fatalf("unexpected dead letter: %+v", dl)
+	}
+
+	err = acr.requeue(ctx, workflowUuid)
+	if err != nil {
+		t.Fatalf("failed to requeue: %s", err)
+	}
+
+	un = getWorkflow(t, clientSet, workflowUuid.String())
+	if isDeadLettered(un) {
+		t.Fatalf("expected workflow not to be dead-lettered, got labels %v", un.GetLabels())
+	}
+	if un.GetLabels()[common.LabelKeyCompleted] != "true" {
+		t.Fatalf("expected other labels to be kept, got labels %v", un.GetLabels())
+	}
+
+	deadLetters, err = listDeadLetters(ctx, acr.s3)
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %s", err)
+	}
+	if len(deadLetters) != 0 {
+		t.Fatalf("expected no dead letters, got %d", len(deadLetters))
+	}
+
+	err = acr.requeue(ctx, workflowUuid)
+	if err == nil {
+		t.Fatal("expected requeueing a workflow that is not dead-lettered to fail")
+	}
+}
+
+func TestDeadLetterOutlivesWorkflow(t *testing.T) {
+	ctx := context.Background()
+	acr, clientSet, event := mkDeadLetterRunner(t)
+	workflowUuid := event.properties.Uuid
+
+	err := acr.recordDeadLetter(event, fmt.Errorf("failed"))
+	if err != nil {
+		t.Fatalf("failed to dead-letter: %s", err)
+	}
+
+	err = clientSet.ArgoprojV1alpha1().Workflows(testNs).Delete(
+		ctx,
+		workflowUuid.String(),
+		metav1.DeleteOptions{},
+	)
+	if err != nil {
+		t.Fatalf("failed to delete workflow: %s", err)
+	}
+
+	deadLetters, err := listDeadLetters(ctx, acr.s3)
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %s", err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].WorkflowUuid != workflowUuid {
+		t.Fatalf("expected the dead letter to outlive the workflow, got %v", deadLetters)
+	}
+
+	// the recorded workflow has no phase, so processing it fails, and
+	// the dead letter must be kept
+	err = acr.requeue(ctx, workflowUuid)
+	if err == nil {
+		t.Fatal("expected requeueing an unprocessable workflow to fail")
+	}
+
+	deadLetters, err = listDeadLetters(ctx, acr.s3)
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %s", err)
+	}
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected the dead letter to be kept, got %d", len(deadLetters))
+	}
+}
+
+func TestRetryDeadLettersExhausted(t *testing.T) {
+	acr, clientSet, event := mkDeadLetterRunner(t)
+	name := event.properties.Uuid.String()
+
+	// under the limit the event is retried
+	event.retries = acr.settings.MaxRetries - 1
+	acr.retry(event, fmt.Errorf("failed"))
+
+	select {
+	case wf := <-acr.wfChan:
+		if wf != event || wf.retries != acr.settings.MaxRetries {
+			t.Fatalf("unexpected retry of event with %d retries", wf.retries)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the event to be retried")
+	}
+
+	if isDeadLettered(getWorkflow(t, clientSet, name)) {
+		t.Fatal("expected workflow not to be dead-lettered before its retries are exhausted")
+	}
+
+	// at the limit it is dead-lettered instead
+	acr.retry(event, fmt.Errorf("failed"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !isDeadLettered(getWorkflow(t, clientSet, name)) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the workflow to be dead-lettered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case <-acr.wfChan:
+		t.Fatal("expected a dead-lettered event not to be retried")
+	default:
+	}
+}
+
+func TestDeadLetterFallsBackToRetry(t *testing.T) {
+	acr, clientSet, event := mkDeadLetterRunner(t)
+	event.retries = acr.settings.MaxRetries
+
+	attempts := 0
+	clientSet.PrependReactor(
+		"patch",
+		"workflows",
+		func(k8stesting.Action) (bool, runtime.Object, error) {
+			attempts++
+			return true, nil, fmt.Errorf("unavailable")
+		},
+	)
+
+	go acr.deadLetter(event, fmt.Errorf("failed"))
+
+	// the event is not dropped, but retried from the start
+	select {
+	case wf := <-acr.wfChan:
+		if wf != event || wf.retries != 1 {
+			t.Fatalf("unexpected retry of event with %d retries", wf.retries)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the event to be retried once dead-lettering gave up")
+	}
+
+	if attempts != acr.settings.MaxRetries+1 {
+		t.Fatalf("expected %d attempts, got %d", acr.settings.MaxRetries+1, attempts)
+	}
+}
@@ -0,0 +1,80 @@
+package argo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj/argo-workflows/v3/workflow/common"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+const propertiesUuid = "f44bb102-a200-4506-bdfb-6a238c33b22d"
+
+func TestWorkflowPropertiesFromLabel(t *testing.T) {
+	// without a db, any lookup would panic
+	acr := &argoCabooseRunner{ctx: context.Background(), settings: config.NewCaboose()}
+
+	_, un := mkWorkflow(t, propertiesUuid, map[string]string{
+		common.LabelKeyPhase:            "Succeeded",
+		config.SwoopWorkflowIdLabelName: "mirror",
+	}, time.Time{}, time.Time{})
+
+	p, err := acr.newWorkflowProperties(un)
+	if err != nil {
+		t.Fatalf("failed to get workflow properties: %s", err)
+	}
+
+	if p.Name != "mirror" {
+		t.Fatalf("expected workflow name 'mirror' from label, got '%s'", p.Name)
+	}
+}
+
+func TestWorkflowPropertiesNotSwoop(t *testing.T) {
+	acr := &argoCabooseRunner{ctx: context.Background(), settings: config.NewCaboose()}
+
+	_, un := mkWorkflow(t, "not-a-uuid", map[string]string{
+		common.LabelKeyPhase: "Succeeded",
+	}, time.Time{}, time.Time{})
+
+	_, err := acr.newWorkflowProperties(un)
+	if !errors.Is(err, errNotSwoopWorkflow) {
+		t.Fatalf("expected unlabeled workflow without a uuid to be skipped, got %v", err)
+	}
+}
+
+func TestIsSwoopWorkflow(t *testing.T) {
+	_, labeled := mkWorkflow(t, propertiesUuid, map[string]string{
+		config.SwoopWorkflowIdLabelName: "mirror",
+	}, time.Time{}, time.Time{})
+	_, unlabeled := mkWorkflow(t, propertiesUuid, map[string]string{}, time.Time{}, time.Time{})
+
+	for _, test := range []struct {
+		name     string
+		legacy   bool
+		un       *unstructured.Unstructured
+		expected bool
+	}{
+		{"labeled", false, labeled, true},
+		{"unlabeled", false, unlabeled, false},
+		{"labeled with legacy lookup", true, labeled, true},
+		{"unlabeled with legacy lookup", true, unlabeled, true},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				settings := config.NewCaboose()
+				settings.LegacyNameLookup = test.legacy
+				acr := &argoCabooseRunner{settings: settings}
+
+				if acr.isSwoopWorkflow(test.un) != test.expected {
+					t.Fatalf("expected isSwoopWorkflow to be %v", test.expected)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,195 @@
+package argo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	wfclientset "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned"
+	commonutil "github.com/argoproj/argo-workflows/v3/util"
+	"github.com/argoproj/argo-workflows/v3/workflow/common"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+const (
+	// ProcessedLabelName marks workflows the caboose has finished processing
+	ProcessedLabelName = "swoop.element84.com/processed"
+	// ProcessedAtAnnotationName records when a workflow was processed
+	ProcessedAtAnnotationName = "swoop.element84.com/processedAt"
+
+	// how often to recheck processed workflows argo is not yet done with,
+	// in case we miss the update when it is
+	notDoneRecheck = 1 * time.Minute
+)
+
+func isProcessed(un *unstructured.Unstructured) bool {
+	return un.GetLabels()[ProcessedLabelName] == "true"
+}
+
+// processedAt returns when the workflow was processed. If the timestamp
+// is missing or bad we fall back to when the workflow finished, or else
+// was created, as these are stable across passes, unlike the current time.
+func processedAt(un *unstructured.Unstructured) time.Time {
+	t, err := time.Parse(time.RFC3339, un.GetAnnotations()[ProcessedAtAnnotationName])
+	if err == nil {
+		return t
+	}
+
+	finishedAt, _, _ := unstructured.NestedString(un.Object, "status", "finishedAt")
+	t, err = time.Parse(time.RFC3339, finishedAt)
+	if err == nil {
+		return t
+	}
+
+	return un.GetCreationTimestamp().Time
+}
+
+// reaper deletes processed workflows once argo is done with them and the
+// grace period has passed. Workflows are queued by key, and are requeued
+// until they can be deleted.
+type reaper struct {
+	ctx         context.Context
+	settings    *config.WorkflowDeletion
+	wfClientSet wfclientset.Interface
+	store       cache.Store
+	queue       workqueue.RateLimitingInterface
+}
+
+func newReaper(
+	ctx context.Context,
+	settings *config.Caboose,
+	wfClientSet wfclientset.Interface,
+	store cache.Store,
+) *reaper {
+	return &reaper{
+		ctx:         ctx,
+		settings:    &settings.WorkflowDeletion,
+		wfClientSet: wfClientSet,
+		store:       store,
+		queue: workqueue.NewRateLimitingQueue(
+			workqueue.NewItemExponentialFailureRateLimiter(
+				settings.MinBackoff,
+				settings.MaxBackoff,
+			),
+		),
+	}
+}
+
+func (r *reaper) enqueue(obj any) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Printf("reaper: failed to get workflow key: %s", err)
+		return
+	}
+	r.queue.Add(key)
+}
+
+func (r *reaper) addInformerHandlers(wfInformer cache.SharedIndexInformer) {
+	wfInformer.AddEventHandler(
+		cache.FilteringResourceEventHandler{
+			FilterFunc: func(obj interface{}) bool {
+				un, ok := obj.(*unstructured.Unstructured)
+				return ok && isProcessed(un)
+			},
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc: r.enqueue,
+				// the queue ignores keys already waiting, so requeueing
+				// on resync is harmless and catches anything missed
+				UpdateFunc: func(_, obj interface{}) {
+					r.enqueue(obj)
+				},
+			},
+		},
+	)
+}
+
+// reap deletes the workflow if it is ready, otherwise returning how long
+// to wait before checking again; a zero wait means it needs no requeue
+func (r *reaper) reap(key string) (time.Duration, error) {
+	obj, exists, err := r.store.GetByKey(key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	un, ok := obj.(*unstructured.Unstructured)
+	if !ok || !isProcessed(un) {
+		return 0, nil
+	}
+
+	if !common.IsDone(un) {
+		// argo still has work to do, such as archiving
+		return notDoneRecheck, nil
+	}
+
+	wait := time.Until(processedAt(un).Add(r.settings.GracePeriod))
+	if wait > 0 {
+		return wait, nil
+	}
+
+	return 0, r.delete(un)
+}
+
+func (r *reaper) delete(un *unstructured.Unstructured) error {
+	err := r.wfClientSet.ArgoprojV1alpha1().Workflows(un.GetNamespace()).Delete(
+		r.ctx,
+		un.GetName(),
+		metav1.DeleteOptions{
+			PropagationPolicy: commonutil.GetDeletePropagation(),
+			// in case the workflow has since been replaced
+			Preconditions: metav1.NewUIDPreconditions(string(un.GetUID())),
+		},
+	)
+	if apierr.IsNotFound(err) {
+		log.Printf("reaper: workflow already deleted '%s/%s'", un.GetNamespace(), un.GetName())
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	log.Printf("reaper: successfully requested to delete workflow '%s/%s'", un.GetNamespace(), un.GetName())
+	return nil
+}
+
+func (r *reaper) processNext() bool {
+	item, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(item)
+
+	key := item.(string)
+	wait, err := r.reap(key)
+	if err != nil {
+		log.Printf("reaper: failed to delete workflow '%s', will retry: %s", key, err)
+		r.queue.AddRateLimited(key)
+		return true
+	}
+
+	r.queue.Forget(key)
+	if wait > 0 {
+		r.queue.AddAfter(key, wait)
+	}
+
+	return true
+}
+
+// Run processes the queue until ctx is done
+func (r *reaper) Run() {
+	go func() {
+		<-r.ctx.Done()
+		r.queue.ShutDown()
+	}()
+
+	for r.processNext() {
+	}
+}
@@ -0,0 +1,181 @@
+package argo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	wffake "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned/fake"
+	"github.com/argoproj/argo-workflows/v3/workflow/common"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+const testNs = "swoop"
+
+func mkWorkflow(
+	t *testing.T,
+	name string,
+	labels map[string]string,
+	processedAt time.Time,
+	finishedAt time.Time,
+) (*v1alpha1.Workflow, *unstructured.Unstructured) {
+	wf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNs,
+			Labels:    labels,
+		},
+	}
+	if !processedAt.IsZero() {
+		wf.Annotations = map[string]string{
+			ProcessedAtAnnotationName: processedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	if !finishedAt.IsZero() {
+		wf.Status.FinishedAt = metav1.NewTime(finishedAt)
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(wf)
+	if err != nil {
+		t.Fatalf("failed to convert workflow: %s", err)
+	}
+
+	return wf, &unstructured.Unstructured{Object: obj}
+}
+
+func TestReaper(t *testing.T) {
+	ctx := context.Background()
+	grace := 10 * time.Minute
+	past := time.Now().Add(-time.Hour)
+
+	done := map[string]string{
+		common.LabelKeyCompleted: "true",
+		ProcessedLabelName:       "true",
+	}
+	archiving := map[string]string{
+		common.LabelKeyCompleted:               "true",
+		common.LabelKeyWorkflowArchivingStatus: "Pending",
+		ProcessedLabelName:                     "true",
+	}
+	unprocessed := map[string]string{
+		common.LabelKeyCompleted: "true",
+	}
+
+	for _, test := range []struct {
+		name        string
+		labels      map[string]string
+		processedAt time.Time
+		finishedAt  time.Time
+		inStore     bool
+		deleted     bool
+		requeued    bool
+	}{
+		{"ready", done, past, time.Time{}, true, true, false},
+		{"in grace period", done, time.Now(), time.Time{}, true, false, true},
+		{"no timestamp, finished", done, time.Time{}, past, true, true, false},
+		{"no timestamp, recently finished", done, time.Time{}, time.Now(), true, false, true},
+		{"archiving", archiving, past, time.Time{}, true, false, true},
+		{"not processed", unprocessed, past, time.Time{}, true, false, false},
+		{"not in store", done, past, time.Time{}, false, false, false},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				wf, un := mkWorkflow(t, "wf", test.labels, test.processedAt, test.finishedAt)
+
+				store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+				if test.inStore {
+					store.Add(un)
+				}
+
+				clientSet := wffake.NewSimpleClientset(wf)
+				settings := config.NewCaboose()
+				settings.WorkflowDeletion.GracePeriod = grace
+				r := newReaper(ctx, settings, clientSet, store)
+
+				wait, err := r.reap(testNs + "/wf")
+				if err != nil {
+					t.Fatalf("failed to reap: %s", err)
+				}
+
+				if (wait > 0) != test.requeued {
+					t.Fatalf("expected requeued to be %v, got wait of %s", test.requeued, wait)
+				}
+				if wait > grace {
+					t.Fatalf("wait of %s should not exceed grace period %s", wait, grace)
+				}
+
+				_, err = clientSet.ArgoprojV1alpha1().Workflows(testNs).Get(ctx, "wf", metav1.GetOptions{})
+				if deleted := apierr.IsNotFound(err); deleted != test.deleted {
+					t.Fatalf("expected deleted to be %v, got: %v", test.deleted, err)
+				}
+			},
+		)
+	}
+}
+
+func TestReaperAlreadyDeleted(t *testing.T) {
+	ctx := context.Background()
+	_, un := mkWorkflow(t, "wf", map[string]string{
+		common.LabelKeyCompleted: "true",
+		ProcessedLabelName:       "true",
+	}, time.Now().Add(-time.Hour), time.Time{})
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	store.Add(un)
+
+	r := newReaper(ctx, config.NewCaboose(), wffake.NewSimpleClientset(), store)
+
+	_, err := r.reap(testNs + "/wf")
+	if err != nil {
+		t.Fatalf("deleting a missing workflow should not fail: %s", err)
+	}
+}
+
+func TestReaperNoTimestamp(t *testing.T) {
+	ctx := context.Background()
+	grace := 1 * time.Second
+
+	// the timestamp is missing, so the grace period runs from when the
+	// workflow finished, rather than restarting with each pass
+	wf, un := mkWorkflow(t, "wf", map[string]string{
+		common.LabelKeyCompleted: "true",
+		ProcessedLabelName:       "true",
+	}, time.Time{}, time.Now())
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	store.Add(un)
+
+	clientSet := wffake.NewSimpleClientset(wf)
+	settings := config.NewCaboose()
+	settings.WorkflowDeletion.GracePeriod = grace
+	r := newReaper(ctx, settings, clientSet, store)
+
+	deadline := time.Now().Add(5 * grace)
+	for {
+		wait, err := r.reap(testNs + "/wf")
+		if err != nil {
+			t.Fatalf("failed to reap: %s", err)
+		}
+		if wait == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("workflow without a timestamp was never deleted")
+		}
+		time.Sleep(wait)
+	}
+
+	_, err := clientSet.ArgoprojV1alpha1().Workflows(testNs).Get(ctx, "wf", metav1.GetOptions{})
+	if !apierr.IsNotFound(err) {
+		t.Fatalf("expected workflow to be deleted, got: %v", err)
+	}
+}
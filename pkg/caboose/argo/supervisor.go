@@ -0,0 +1,198 @@
+package argo
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/element84/swoop-go/pkg/utils"
+)
+
+var (
+	// how long past the event timeout a worker may be busy before it is
+	// considered hung; events should stop soon after their deadline, so
+	// a worker still busy after this is ignoring its context
+	hangGrace = 30 * time.Second
+	// how often supervisors check on their workers
+	superviseInterval = 5 * time.Second
+)
+
+// worker processes workflow events until ctx is done, or until it is
+// abandoned by its supervisor for hanging
+type worker struct {
+	id     int
+	handle func(*workflowEvent)
+	// start time of the current event in unix nanos, or 0 if idle
+	busySince atomic.Int64
+	current   atomic.Pointer[workflowEvent]
+	abandoned atomic.Bool
+}
+
+// busyFor returns the event being processed and how long it has taken
+func (w *worker) busyFor() (*workflowEvent, time.Duration) {
+	since := w.busySince.Load()
+	if since == 0 {
+		return nil, 0
+	}
+	return w.current.Load(), time.Since(time.Unix(0, since))
+}
+
+// runWorker processes events, returning an error if processing one panics
+func (acr *argoCabooseRunner) runWorker(w *worker) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+
+		// the event may have panicked due to a transient problem
+		if wf := w.current.Load(); wf != nil {
+			acr.retry(wf, err)
+		}
+	}()
+
+	for {
+		// first select is to give priority to ctx.Done
+		select {
+		case <-acr.ctx.Done():
+			return nil
+		default:
+		}
+
+		select {
+		case wf := <-acr.wfChan:
+			w.current.Store(wf)
+			w.busySince.Store(time.Now().UnixNano())
+
+			w.handle(wf)
+
+			w.busySince.Store(0)
+			w.current.Store(nil)
+		case <-acr.ctx.Done():
+			return nil
+		}
+
+		if w.abandoned.Load() {
+			return fmt.Errorf("worker was replaced while hung")
+		}
+	}
+}
+
+// watch waits for the worker to exit, returning its error, or for it to
+// hang, in which case the worker is abandoned and an error returned
+func (acr *argoCabooseRunner) watch(w *worker, done <-chan error) error {
+	ticker := time.NewTicker(superviseInterval)
+	defer ticker.Stop()
+
+	// the last event we warned was slow, so we warn only once per event
+	var slow *workflowEvent
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-acr.ctx.Done():
+			// give the worker a chance to finish its current event
+			select {
+			case <-done:
+			case <-time.After(hangGrace):
+				log.Printf("worker %d did not stop, abandoning it", w.id)
+			}
+			return nil
+		case <-ticker.C:
+			wf, busy := w.busyFor()
+			if wf == nil || busy <= acr.settings.EventTimeout {
+				continue
+			}
+
+			if wf != slow {
+				slow = wf
+				log.Printf(
+					"WARNING: worker %d has exceeded the event timeout of %s processing an event, busy for %s",
+					w.id,
+					acr.settings.EventTimeout,
+					busy,
+				)
+			}
+
+			if busy <= acr.settings.EventTimeout+hangGrace {
+				continue
+			}
+
+			// We don't retry the event here, as the hung worker may still
+			// hold its transaction. The worker retries the event itself if
+			// it ever finishes it, once its transaction has been rolled back.
+			w.abandoned.Store(true)
+			return fmt.Errorf("worker %d hung for %s processing event", w.id, busy)
+		}
+	}
+}
+
+// supervise keeps a worker running for the life of ctx, restarting it with
+// backoff if it panics and replacing it if it hangs. A hung worker keeps
+// its goroutine and any database connection until it finishes, so to bound
+// these each supervisor only leaves one hung worker behind at a time,
+// waiting for it to finish before replacing another.
+func (acr *argoCabooseRunner) supervise(id int, handle func(*workflowEvent)) {
+	defer acr.wg.Done()
+
+	attempt := 0
+	// the abandoned worker, if any, is done once this has a value
+	var abandoned <-chan error
+	for {
+		w := &worker{id: id, handle: handle}
+		done := make(chan error, 1)
+		started := time.Now()
+
+		log.Printf("starting worker %d", id)
+		go func() {
+			done <- acr.runWorker(w)
+		}()
+
+		err := acr.watch(w, done)
+		if acr.ctx.Err() != nil {
+			log.Printf("stopping worker %d", id)
+			return
+		}
+
+		if w.abandoned.Load() {
+			if abandoned != nil {
+				log.Printf("worker %d waiting for previously hung worker to finish", id)
+				select {
+				case <-acr.ctx.Done():
+					return
+				case <-abandoned:
+				}
+			}
+			abandoned = done
+		}
+
+		// a worker that ran a while was healthy, so we start over
+		if time.Since(started) > acr.settings.MaxBackoff {
+			attempt = 0
+		}
+
+		backoff := time.Duration(utils.IntPow(2, min(attempt, 16))) * acr.settings.MinBackoff
+		backoff = min(backoff, acr.settings.MaxBackoff)
+		attempt++
+
+		log.Printf("worker %d failed, restarting in %s: %s", id, backoff, err)
+
+		select {
+		case <-acr.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (acr *argoCabooseRunner) startWorkers() {
+	for i := 0; i < acr.settings.MaxWorkers; i++ {
+		acr.wg.Add(1)
+		go acr.supervise(i, acr.process)
+	}
+}
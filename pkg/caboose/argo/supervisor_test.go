@@ -0,0 +1,167 @@
+package argo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+func mkSupervisedRunner(t *testing.T) (*argoCabooseRunner, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	settings := config.NewCaboose()
+	settings.MinBackoff = 10 * time.Millisecond
+	settings.MaxBackoff = 50 * time.Millisecond
+	settings.EventTimeout = 20 * time.Millisecond
+
+	var wg sync.WaitGroup
+	acr := &argoCabooseRunner{
+		ctx:      ctx,
+		settings: settings,
+		wg:       &wg,
+		wfChan:   make(chan *workflowEvent),
+	}
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	return acr, cancel
+}
+
+func sendEvent(t *testing.T, acr *argoCabooseRunner, wf *workflowEvent) {
+	select {
+	case acr.wfChan <- wf:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a worker to take the event")
+	}
+}
+
+func waitHandled(t *testing.T, handled <-chan *workflowEvent, expected *workflowEvent) {
+	select {
+	case wf := <-handled:
+		if wf != expected {
+			t.Fatal("unexpected event handled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the event to be handled")
+	}
+}
+
+func TestSupervisorRestartsPanickedWorker(t *testing.T) {
+	acr, _ := mkSupervisedRunner(t)
+
+	panicky := &workflowEvent{eventType: started}
+	handled := make(chan *workflowEvent, 1)
+
+	acr.wg.Add(1)
+	go acr.supervise(0, func(wf *workflowEvent) {
+		if wf == panicky {
+			panic("boom")
+		}
+		handled <- wf
+	})
+
+	sendEvent(t, acr, panicky)
+
+	next := &workflowEvent{eventType: started}
+	sendEvent(t, acr, next)
+	waitHandled(t, handled, next)
+}
+
+func TestSupervisorReplacesHungWorker(t *testing.T) {
+	hangGrace, superviseInterval = 10*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() {
+		hangGrace, superviseInterval = 30*time.Second, 5*time.Second
+	})
+
+	acr, _ := mkSupervisedRunner(t)
+
+	hung := &workflowEvent{eventType: started}
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	handled := make(chan *workflowEvent, 1)
+
+	acr.wg.Add(1)
+	go acr.supervise(0, func(wf *workflowEvent) {
+		if wf == hung {
+			<-release
+			return
+		}
+		handled <- wf
+	})
+
+	sendEvent(t, acr, hung)
+
+	next := &workflowEvent{eventType: started}
+	sendEvent(t, acr, next)
+	waitHandled(t, handled, next)
+}
+
+func TestSupervisorBoundsHungWorkers(t *testing.T) {
+	hangGrace, superviseInterval = 10*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() {
+		hangGrace, superviseInterval = 30*time.Second, 5*time.Second
+	})
+
+	acr, _ := mkSupervisedRunner(t)
+
+	first := &workflowEvent{eventType: started}
+	second := &workflowEvent{eventType: started}
+	releaseFirst := make(chan struct{})
+	releaseSecond := make(chan struct{})
+	t.Cleanup(func() { close(releaseSecond) })
+	handled := make(chan *workflowEvent, 1)
+
+	acr.wg.Add(1)
+	go acr.supervise(0, func(wf *workflowEvent) {
+		switch wf {
+		case first:
+			<-releaseFirst
+		case second:
+			<-releaseSecond
+		default:
+			handled <- wf
+		}
+	})
+
+	sendEvent(t, acr, first)
+	sendEvent(t, acr, second)
+
+	// the second hung worker is not replaced while the first is outstanding
+	next := &workflowEvent{eventType: started}
+	select {
+	case acr.wfChan <- next:
+		t.Fatal("expected no worker to take the event while a hung worker is outstanding")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(releaseFirst)
+	sendEvent(t, acr, next)
+	waitHandled(t, handled, next)
+}
+
+func TestSupervisorStops(t *testing.T) {
+	acr, cancel := mkSupervisedRunner(t)
+
+	acr.wg.Add(1)
+	go acr.supervise(0, func(*workflowEvent) {})
+
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		acr.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("supervisor did not stop")
+	}
+}
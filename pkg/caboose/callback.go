@@ -91,28 +91,42 @@ func (cbx *CallbackExecutor) extractParams(
 	return &params, nil
 }
 
+// noFeature is the feature index for callbacks not made per feature
+const noFeature = -1
+
+// callbackUuid is deterministic for the workflow, callback, and feature,
+// so processing a workflow again cannot create duplicate callbacks
+func callbackUuid(wfUuid uuid.UUID, name string, featIdx int) uuid.UUID {
+	key := fmt.Sprintf("%q", name)
+	if featIdx != noFeature {
+		key = fmt.Sprintf("%s/%d", key, featIdx)
+	}
+	return uuid.NewV5(wfUuid, key)
+}
+
+// insertCallback returns the callback uuid, and false if the callback
+// already exists, in which case it was fully processed when the workflow
+// was last processed, as that was done in a single transaction
 func (cbx *CallbackExecutor) insertCallback(
 	name string,
 	handlerName string,
 	handlerType config.HandlerType,
 	wfUuid uuid.UUID,
-) (uuid.UUID, error) {
-	cbUuid, err := db.NewCallbackAction(
+	featIdx int,
+) (uuid.UUID, bool, error) {
+	cbUuid := callbackUuid(wfUuid, name, featIdx)
+	inserted, err := db.NewCallbackActionWithUuid(
+		cbUuid,
 		name,
 		handlerName,
 		handlerType.String(),
 		wfUuid,
-	).Insert(cbx.ctx, cbx.conn)
-
+	).InsertWithUuid(cbx.ctx, cbx.conn)
 	if err != nil {
-		return uuid.UUID{}, err
-
-	}
-	if cbUuid.IsNil() {
-		return uuid.UUID{}, fmt.Errorf("no uuid returned for callback action")
+		return uuid.UUID{}, false, err
 	}
 
-	return cbUuid, nil
+	return cbUuid, inserted, nil
 }
 
 func (cbx *CallbackExecutor) failCallback(cbUuid uuid.UUID, err error) error {
@@ -138,21 +152,28 @@ func (cbx *CallbackExecutor) insertAndFailCallback(
 	wfUuid uuid.UUID,
 	err error,
 ) error {
-	cbUuid, _err := cbx.insertCallback(name, handlerName, handlerType, wfUuid)
+	cbUuid, inserted, _err := cbx.insertCallback(name, handlerName, handlerType, wfUuid, noFeature)
 	if _err != nil {
 		return _err
 	}
+	if !inserted {
+		return nil
+	}
 
 	return cbx.failCallback(cbUuid, err)
 }
 
 func (cbx *CallbackExecutor) processCallback(
-	cb *config.Callback, wfProps *WorkflowProperties, data *map[string]any,
+	cb *config.Callback, wfProps *WorkflowProperties, data *map[string]any, featIdx int,
 ) error {
-	cbUuid, err := cbx.insertCallback(cb.Name, cb.HandlerName, cb.Handler.Type, wfProps.Uuid)
+	cbUuid, inserted, err := cbx.insertCallback(cb.Name, cb.HandlerName, cb.Handler.Type, wfProps.Uuid, featIdx)
 	if err != nil {
 		return err
 	}
+	if !inserted {
+		log.Printf("callback '%s' already exists, skipping: %s", cb.Name, cbUuid)
+		return nil
+	}
 
 	params, err := cbx.extractParams(cb.Parameters, cb.ValidateParams, data)
 	if err != nil {
@@ -207,7 +228,7 @@ func (cbx *CallbackExecutor) ProcessCallbacks(cbs Callbacks, wfProps *WorkflowPr
 	for _, callback := range cbs {
 		switch callback.Type {
 		case config.SingleCallback:
-			err := cbx.processCallback(callback, wfProps, &data)
+			err := cbx.processCallback(callback, wfProps, &data, noFeature)
 			if err != nil {
 				// if we get an error back here it is possibly a transient problem
 				// we return it to bubble it up to the general workflow retry mechanism
@@ -250,7 +271,7 @@ func (cbx *CallbackExecutor) ProcessCallbacks(cbs Callbacks, wfProps *WorkflowPr
 			for featIdx, feature := range features {
 				// TODO: need to filter features with callback's filter
 				data["feature"] = feature
-				err := cbx.processCallback(callback, wfProps, &data)
+				err := cbx.processCallback(callback, wfProps, &data, featIdx)
 				if err != nil {
 					// if we get an error back here it is possibly a transient problem
 					// we return it to bubble it up to the general workflow retry mechanism
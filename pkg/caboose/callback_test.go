@@ -45,4 +45,75 @@ func TestCallbacks(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to process callbacks: %s", err)
 	}
+
+	countActions := func() int {
+		var count int
+		err := db.QueryRow(
+			ctx,
+			"SELECT count(*) FROM swoop.action WHERE parent_uuid = $1",
+			wfProps.Uuid,
+		).Scan(&count)
+		if err != nil {
+			t.Fatalf("failed to count callback actions: %s", err)
+		}
+		return count
+	}
+
+	countEvents := func() int {
+		var count int
+		err := db.QueryRow(
+			ctx,
+			`SELECT count(*)
+			FROM swoop.event AS e
+			JOIN swoop.action AS a USING (action_uuid)
+			WHERE a.parent_uuid = $1`,
+			wfProps.Uuid,
+		).Scan(&count)
+		if err != nil {
+			t.Fatalf("failed to count callback events: %s", err)
+		}
+		return count
+	}
+
+	count := countActions()
+	if count == 0 {
+		t.Fatal("expected callback actions to be inserted")
+	}
+	events := countEvents()
+
+	// processing the workflow again must not duplicate its callbacks
+	err = cbx.ProcessCallbacks(callbacks, wfProps)
+	if err != nil {
+		t.Fatalf("failed to process callbacks again: %s", err)
+	}
+
+	if again := countActions(); again != count {
+		t.Fatalf("expected %d callback actions after reprocessing, got %d", count, again)
+	}
+
+	// nor should it add events, such as failures, to existing callbacks
+	if again := countEvents(); again != events {
+		t.Fatalf("expected %d callback events after reprocessing, got %d", events, again)
+	}
+}
+
+func TestCallbackUuid(t *testing.T) {
+	wfUuid := uuid.Must(uuid.FromString("f44bb102-a200-4506-bdfb-6a238c33b22d"))
+	otherWfUuid := uuid.Must(uuid.FromString("0d2e3e7c-0e49-4d1d-bb45-43b4a8a9d0f1"))
+
+	first := callbackUuid(wfUuid, "publish", 0)
+	if first != callbackUuid(wfUuid, "publish", 0) {
+		t.Fatal("callback uuid should be deterministic")
+	}
+
+	for _, other := range []uuid.UUID{
+		callbackUuid(otherWfUuid, "publish", 0),
+		callbackUuid(wfUuid, "notify", 0),
+		callbackUuid(wfUuid, "publish", 1),
+		callbackUuid(wfUuid, "publish", noFeature),
+	} {
+		if other == first {
+			t.Fatalf("callback uuid should differ, got '%s' for both", first)
+		}
+	}
 }
@@ -71,9 +71,15 @@ type ArgoClient struct {
 	serviceClient workflowpkg.WorkflowServiceClient
 	namespace     string
 	workflows     map[string]*ArgoWorkflow
+	backoff       *config.HandlerBackoff
 }
 
-func NewArgoClient(ctx context.Context, ac *config.ArgoConf, wfs []*config.Workflow) (*ArgoClient, error) {
+func NewArgoClient(
+	ctx context.Context,
+	ac *config.ArgoConf,
+	wfs []*config.Workflow,
+	backoff *config.HandlerBackoff,
+) (*ArgoClient, error) {
 	ctx, client, err := apiclient.NewClientFromOpts(
 		apiclient.Opts{
 			// right now only supports direct connection to k8s api
@@ -111,6 +117,7 @@ func NewArgoClient(ctx context.Context, ac *config.ArgoConf, wfs []*config.Workf
 		serviceClient: client.NewWorkflowServiceClient(),
 		namespace:     namespace,
 		workflows:     workflows,
+		backoff:       backoff,
 	}, nil
 }
 
@@ -132,5 +139,5 @@ func (ac *ArgoClient) HandleAction(ctx context.Context, conn db.Conn, thread *db
 	handleFn := func() error {
 		return ac.SubmitWorkflow(ctx, *thread.ActionName, thread.Uuid, thread.Priority)
 	}
-	return HandleActionWrapper(ctx, conn, thread, true, handleFn)
+	return HandleActionWrapper(ctx, conn, thread, true, ac.backoff, handleFn)
 }
@@ -0,0 +1,114 @@
+package conductor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/db"
+	swooperrs "github.com/element84/swoop-go/pkg/errors"
+	"github.com/element84/swoop-go/pkg/s3"
+)
+
+type CirrusClient struct {
+	sqs       sqsiface.SQSAPI
+	sqsUrl    string
+	s3        *s3.SwoopS3
+	workflows map[string]*config.CirrusWorkflowOpts
+	backoff   *config.HandlerBackoff
+}
+
+func NewCirrusClient(
+	cc *config.CirrusConf,
+	wfs []*config.Workflow,
+	backoff *config.HandlerBackoff,
+	s3 *s3.SwoopS3,
+) (*CirrusClient, error) {
+	if cc == nil {
+		return nil, errors.New("cannot create CirrusClient without Handler.CirrusConf defined")
+	}
+
+	awsConf := aws.Config{}
+	if cc.Region != "" {
+		awsConf.Region = aws.String(cc.Region)
+	}
+	if cc.Endpoint != "" {
+		awsConf.Endpoint = aws.String(cc.Endpoint)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsConf,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := make(map[string]*config.CirrusWorkflowOpts, len(wfs))
+	for _, wf := range wfs {
+		if wf.CirrusOpts == nil {
+			return nil, errors.New("cannot create cirrus workflow without Workflow.CirrusOpts defined")
+		}
+		workflows[wf.Id] = wf.CirrusOpts
+	}
+
+	return &CirrusClient{
+		sqs:       sqs.New(sess),
+		sqsUrl:    cc.SqsUrl,
+		s3:        s3,
+		workflows: workflows,
+		backoff:   backoff,
+	}, nil
+}
+
+func (cc *CirrusClient) SubmitWorkflow(
+	ctx context.Context,
+	wfId string,
+	wfUuid uuid.UUID,
+	input any,
+) error {
+	wf, ok := cc.workflows[wfId]
+	if !ok {
+		return swooperrs.NewRequestError(fmt.Errorf("unknown workflow '%s'", wfId), false)
+	}
+
+	payload, err := wf.DecoratePayload(input, wfId, wfUuid)
+	if err != nil {
+		return swooperrs.NewRequestError(err, false)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return swooperrs.NewRequestError(err, false)
+	}
+
+	_, err = cc.sqs.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(cc.sqsUrl),
+		MessageBody: aws.String(string(body)),
+	})
+	if err != nil {
+		return swooperrs.NewRequestError(fmt.Errorf("failed to send cirrus payload: %s", err), true)
+	}
+
+	return nil
+}
+
+func (cc *CirrusClient) HandleAction(ctx context.Context, conn db.Conn, thread *db.Thread) error {
+	handleFn := func() error {
+		input, err := cc.s3.GetInput(ctx, thread.Uuid)
+		if err != nil {
+			return objectError(err)
+		}
+
+		return cc.SubmitWorkflow(ctx, *thread.ActionName, thread.Uuid, input)
+	}
+	return HandleActionWrapper(ctx, conn, thread, true, cc.backoff, handleFn)
+}
@@ -0,0 +1,94 @@
+package conductor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+func Test_CirrusSubmitWorkflow(t *testing.T) {
+	endpoint := os.Getenv("SWOOP_SQS_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("SWOOP_SQS_ENDPOINT not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// elasticmq accepts any credentials
+	for k, v := range map[string]string{
+		"AWS_ACCESS_KEY_ID":     "x",
+		"AWS_SECRET_ACCESS_KEY": "x",
+		"AWS_REGION":            "elasticmq",
+	} {
+		if os.Getenv(k) == "" {
+			t.Setenv(k, v)
+		}
+	}
+
+	wfs := []*config.Workflow{
+		{Id: "mirror", CirrusOpts: &config.CirrusWorkflowOpts{}},
+	}
+	cc, err := NewCirrusClient(
+		&config.CirrusConf{Endpoint: endpoint},
+		wfs,
+		config.NewHandlerBackoff(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("failed to make cirrus client: %s", err)
+	}
+
+	queue, err := cc.sqs.CreateQueueWithContext(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(strings.ToLower(t.Name())),
+	})
+	if err != nil {
+		t.Fatalf("failed to create queue: %s", err)
+	}
+	cc.sqsUrl = *queue.QueueUrl
+	t.Cleanup(func() {
+		cc.sqs.DeleteQueue(&sqs.DeleteQueueInput{QueueUrl: queue.QueueUrl})
+	})
+
+	wfUuid := uuid.Must(uuid.NewV4())
+	err = cc.SubmitWorkflow(ctx, "mirror", wfUuid, map[string]any{"features": []any{}})
+	if err != nil {
+		t.Fatalf("failed to submit workflow: %s", err)
+	}
+
+	err = cc.SubmitWorkflow(ctx, "unknown", wfUuid, map[string]any{})
+	if err == nil || isRetryable(err) {
+		t.Fatalf("unknown workflow should be a non-retryable error, got: %v", err)
+	}
+
+	out, err := cc.sqs.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:        queue.QueueUrl,
+		WaitTimeSeconds: aws.Int64(5),
+	})
+	if err != nil {
+		t.Fatalf("failed to receive message: %s", err)
+	}
+	if len(out.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(out.Messages))
+	}
+
+	payload := map[string]any{}
+	err = json.Unmarshal([]byte(*out.Messages[0].Body), &payload)
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+
+	process := payload["process"].(map[string]any)
+	if process["workflow"] != "mirror" || process["swoop_workflow_uuid"] != wfUuid.String() {
+		t.Fatalf("unexpected process block: %v", process)
+	}
+}
@@ -2,18 +2,21 @@ package conductor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"sync"
 	"syscall"
 
+	"github.com/gofrs/uuid/v5"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	"github.com/element84/swoop-go/pkg/config"
 	"github.com/element84/swoop-go/pkg/db"
 	"github.com/element84/swoop-go/pkg/s3"
+	"github.com/element84/swoop-go/pkg/secrets"
 )
 
 type PgConductor struct {
@@ -21,6 +24,9 @@ type PgConductor struct {
 	S3           *s3.SwoopS3
 	SwoopConfig  *config.SwoopConfig
 	DbConfig     *db.ConnectConfig
+	// WebhookSecret is used to sign the tokens for asynchttp callbacks
+	WebhookSecret string
+	webhook       *webhook
 }
 
 func (c *PgConductor) AddFlags(fs *pflag.FlagSet) {
@@ -32,6 +38,12 @@ func (c *PgConductor) AddFlags(fs *pflag.FlagSet) {
 		"conductor instance name (required; SWOOP_CONDUCTOR_INSTANCE)",
 	)
 	cobra.MarkFlagRequired(fs, "conductor-instance")
+	fs.StringVar(
+		&c.WebhookSecret,
+		"webhook-secret",
+		"",
+		"secret for asynchttp callback tokens (SWOOP_WEBHOOK_SECRET)",
+	)
 }
 
 func (c *PgConductor) Run(ctx context.Context, cancel context.CancelFunc) error {
@@ -41,14 +53,23 @@ func (c *PgConductor) Run(ctx context.Context, cancel context.CancelFunc) error
 		return fmt.Errorf("no conductor config for instance '%s'", c.InstanceName)
 	}
 
+	settings := conf.Settings
 	handlerConfs := conf.Handlers
 	if len(handlerConfs) == 0 {
 		return fmt.Errorf("no handlers specified for conductor instance '%s'", c.InstanceName)
 	}
 
+	if settings.Webhook != nil {
+		wh, err := newWebhook(settings.Webhook, c.WebhookSecret)
+		if err != nil {
+			return err
+		}
+		c.webhook = wh
+	}
+
 	handlers := []*Handler{}
 	for _, conf := range handlerConfs {
-		handler, err := c.NewHandlerFromConfig(ctx, conf)
+		handler, err := c.NewHandlerFromConfig(ctx, conf, settings)
 		if err != nil {
 			// TODO: I think this should be an error, not just logged?
 			log.Println(err)
@@ -58,8 +79,35 @@ func (c *PgConductor) Run(ctx context.Context, cancel context.CancelFunc) error
 		handlers = append(handlers, handler)
 	}
 
+	// handlers use their own worker pools unless a shared pool is configured
+	if settings.Workers > 0 {
+		pool := newWorkerPool(settings.Workers)
+		for _, handler := range handlers {
+			handler.pool = pool
+		}
+	}
+	for _, handler := range handlers {
+		handler.pool.Start(ctx)
+	}
+
+	// start receiving asynchttp results
+	if c.webhook != nil {
+		pool, err := (&db.PoolConfig{ConnectConfig: *c.DbConfig}).Connect(ctx)
+		if err != nil {
+			return err
+		}
+		defer pool.Close()
+
+		go func() {
+			err := c.webhook.Serve(ctx, pool)
+			if err != nil {
+				log.Printf("webhook server failed: %s", err)
+				cancel()
+			}
+		}()
+	}
+
 	// start listening
-	// TODO: how to keep it listening, maybe with backoff?
 	err := db.Listen(ctx, c.DbConfig, handlers)
 	if err != nil {
 		return err
@@ -74,9 +122,10 @@ func (c *PgConductor) Run(ctx context.Context, cancel context.CancelFunc) error
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			// handlers reconnect on their own, so only return once ctx is done
 			err := handler.Start(ctx, c.DbConfig)
 			if err != nil {
-				panic(err)
+				log.Printf("handler %s: stopped: %s", handler.GetName(), err)
 			}
 		}()
 	}
@@ -106,25 +155,64 @@ func (c *PgConductor) SignalHandler(
 	}
 }
 
-func (c *PgConductor) NewHandlerFromConfig(ctx context.Context, conf *config.Handler) (*Handler, error) {
+func (c *PgConductor) NewHandlerFromConfig(
+	ctx context.Context,
+	conf *config.Handler,
+	settings *config.ConductorSettings,
+) (*Handler, error) {
 	var client HandlerClient
 	switch conf.Type {
 	case config.ArgoWorkflows:
-		cl, err := NewArgoClient(ctx, conf.ArgoConf, conf.Workflows)
+		cl, err := NewArgoClient(ctx, conf.ArgoConf, conf.Workflows, conf.Backoff)
 		if err != nil {
 			return nil, fmt.Errorf("failed making argo client: %s", err)
 		}
 		client = cl
+	case config.Cirrus:
+		cl, err := NewCirrusClient(conf.CirrusConf, conf.Workflows, conf.Backoff, c.S3)
+		if err != nil {
+			return nil, fmt.Errorf("failed making cirrus client: %s", err)
+		}
+		client = cl
+	case config.Noop:
+		client = newNoopClient(conf.NoopConf, conf.Backoff)
 	case config.SyncHttp:
-		client = newSyncHttpClient(conf.HttpClient, c.S3)
+		s, err := secrets.New(ctx, conf.Secrets)
+		if err != nil {
+			return nil, err
+		}
+		client = newSyncHttpClient(conf.HttpClient, s, conf.Backoff, c.S3)
+	case config.AsyncHttp:
+		if c.webhook == nil {
+			return nil, errors.New("asynchttp handlers require conductor webhook settings")
+		}
+		s, err := secrets.New(ctx, conf.Secrets)
+		if err != nil {
+			return nil, err
+		}
+		c.webhook.addHandler(conf.Name)
+		client = newAsyncHttpClient(conf.HttpClient, s, conf.Backoff, c.S3, c.webhook)
 	default:
 		return nil, fmt.Errorf("unsupported handler type: '%s'", conf.Type)
 	}
 
-	return &Handler{
+	maxConcurrency := conf.Limits.GetMaxConcurrency()
+	handler := &Handler{
 		name:       conf.Name,
 		isNotified: make(chan nothing, 1),
 		conf:       conf,
+		settings:   settings,
 		client:     client,
-	}, nil
+		limiter:    newLimiter(conf.Limits.GetRate(), maxConcurrency),
+		retries:    newRetryScheduler(),
+		pool:       newWorkerPool(maxConcurrency),
+		inFlight:   map[uuid.UUID]nothing{},
+	}
+	handler.debouncer = newDebouncer(
+		conf.Debounce.GetWindow(),
+		conf.Debounce.GetCount(),
+		handler.NotifyNow,
+	)
+
+	return handler, nil
 }
@@ -0,0 +1,65 @@
+package conductor
+
+import (
+	"sync"
+	"time"
+)
+
+// debouncer collapses bursts of notifications into fewer calls to notify.
+// The first held notification opens a window, and notify is called when
+// the window closes or the count of held notifications reaches the
+// threshold, whichever comes first. Without a window, every notification
+// is passed straight through.
+type debouncer struct {
+	window time.Duration
+	count  int
+	notify func()
+
+	mu      sync.Mutex
+	pending int
+	timer   *time.Timer
+}
+
+func newDebouncer(window time.Duration, count int, notify func()) *debouncer {
+	return &debouncer{
+		window: window,
+		count:  count,
+		notify: notify,
+	}
+}
+
+func (d *debouncer) Notify() {
+	if d.window <= 0 {
+		d.notify()
+		return
+	}
+
+	d.mu.Lock()
+	d.pending++
+	if d.count > 0 && d.pending >= d.count {
+		d.reset()
+		d.mu.Unlock()
+		d.notify()
+		return
+	}
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.window, d.flush)
+	}
+	d.mu.Unlock()
+}
+
+func (d *debouncer) flush() {
+	d.mu.Lock()
+	d.reset()
+	d.mu.Unlock()
+	d.notify()
+}
+
+// reset clears any held notifications; callers must hold the lock
+func (d *debouncer) reset() {
+	d.pending = 0
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
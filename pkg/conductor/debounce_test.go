@@ -0,0 +1,54 @@
+package conductor
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_DebouncerPassthrough(t *testing.T) {
+	var calls atomic.Int32
+	d := newDebouncer(0, 0, func() { calls.Add(1) })
+
+	for i := 0; i < 5; i++ {
+		d.Notify()
+	}
+
+	if n := calls.Load(); n != 5 {
+		t.Fatalf("expected 5 notifications without a window, got %d", n)
+	}
+}
+
+func Test_DebouncerCount(t *testing.T) {
+	var calls atomic.Int32
+	d := newDebouncer(time.Hour, 10, func() { calls.Add(1) })
+
+	for i := 0; i < 25; i++ {
+		d.Notify()
+	}
+
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 notifications per count threshold, got %d", n)
+	}
+}
+
+func Test_DebouncerWindow(t *testing.T) {
+	notified := make(chan nothing, 10)
+	d := newDebouncer(50*time.Millisecond, 0, func() { notified <- nothing{} })
+
+	for i := 0; i < 100; i++ {
+		d.Notify()
+	}
+
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for window to close")
+	}
+
+	select {
+	case <-notified:
+		t.Fatal("burst should have collapsed into a single notification")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -8,38 +8,36 @@ import (
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/element84/swoop-go/pkg/config"
 	"github.com/element84/swoop-go/pkg/db"
 	"github.com/element84/swoop-go/pkg/errors"
-)
-
-const (
-	// TODO: make this a top-level config param
-	pollInterval = 600 * time.Second
-	// TODO: handler parameter, related to rate limiting and max concurrency
-	batchSize = 100
+	"github.com/element84/swoop-go/pkg/s3"
 )
 
 type nothing struct{}
 
+// HandleActionWrapper runs the action in a transaction with its resulting
+// event, so the event is only recorded if the action succeeds. The conn
+// must support transactions, and so should not be the connection holding
+// the thread lock, which the caller is responsible for releasing.
 func HandleActionWrapper(
 	ctx context.Context,
 	conn db.Conn,
 	thread *db.Thread,
 	isAsyncAction bool,
+	backoff *config.HandlerBackoff,
 	handleFn func() error,
 ) error {
-	var err error
-	// TODO: need a test to verify we don't leak locks
-	defer thread.Unlock(ctx, conn)
-
 	tx, err := conn.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
+	// the event takes the transaction start time, so it cannot be ordered
+	// after any events the action itself may cause
 	if isAsyncAction {
 		err = thread.InsertQueuedEvent(ctx, tx)
 	} else {
@@ -49,33 +47,32 @@ func HandleActionWrapper(
 		return err
 	}
 
-	handleError := func(_err error, retryable bool) error {
+	// returns the retry seconds if a backoff was inserted
+	handleError := func(_err error, retryable bool) (int, error) {
+		// the action may have failed because ctx was cancelled, but we
+		// must still record its result
+		ctx := context.WithoutCancel(ctx)
+
 		err := tx.Rollback(ctx)
 		if err != nil {
-			return err
+			return 0, err
+		}
+
+		if !retryable {
+			return 0, thread.InsertFailedEvent(ctx, conn, _err.Error())
 		}
 
-		if retryable {
-			// TODO: we don't have a way to track attempts
-			//  -> can't calculate backoff
-			//  -> can't calculate retries exhausted
-			// Once we can, maybe put this logic in a higher-level function on the thread type?
-			// TODO: need to schedule handler poll once this backoff is due, else we'll miss it
-			return thread.InsertBackoffEvent(ctx, conn, 60, _err.Error())
+		retrySeconds, ok := backoffSeconds(backoff, thread.Retries, _err)
+		if !ok {
+			return 0, thread.InsertRetriesExhaustedEvent(ctx, conn, _err.Error())
 		}
 
-		return thread.InsertFailedEvent(ctx, conn, _err.Error())
+		return retrySeconds, thread.InsertBackoffEvent(ctx, conn, retrySeconds, _err.Error())
 	}
 
 	err = handleFn()
 	if err != nil {
-		retryable := true
-		re, ok := err.(*errors.RequestError)
-		if ok {
-			retryable = re.Retryable
-		}
-
-		_err := handleError(err, retryable)
+		retrySeconds, _err := handleError(err, isRetryable(err))
 		if _err != nil {
 			return fmt.Errorf(
 				"error running action: %s; while handling that error encountered another: %s",
@@ -83,83 +80,104 @@ func HandleActionWrapper(
 				_err,
 			)
 		}
+
+		if retrySeconds > 0 {
+			return &backoffError{
+				err:     err,
+				retryAt: time.Now().Add(time.Duration(retrySeconds) * time.Second),
+			}
+		}
+
 		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
-type HandlerClient interface {
-	HandleAction(ctx context.Context, conn db.Conn, thread *db.Thread) error
+// backoffError is returned when an action failed and has been scheduled for retry
+type backoffError struct {
+	err     error
+	retryAt time.Time
 }
 
-type Handler struct {
-	name       string
-	isNotified chan nothing
-	conf       *config.Handler
-	client     HandlerClient
+func (be *backoffError) Error() string {
+	return be.err.Error()
 }
 
-/*
-SOME INCOHERENT AND LIKELY MISLEADING NOTES:
-
-rate limit (configuredLimit) is actions per Second
-- failure halves limit to Min(limit, Every(maxBackoff))
-- success doubles limit to Max(limit, configuredLimit)
-  - we don't actually want to update the rate on every completion
-  - periodic rate update method takes successCount - failureCount to derive new limit
-
-also need a maxConcurrency specifying how many actions can be in process at any given time
-- defaults to Max(int(configuredLimit), 1)
-- queryLimit is Min(maxConcurrency - currentActions, availableTokens)
-
-  - we can work out the rate limiting with https://pkg.go.dev/golang.org/x/time/rate
-
-  - we can work out the concurrency limit with https://pkg.go.dev/golang.org/x/sync/semaphore
+func (be *backoffError) Unwrap() error {
+	return be.err
+}
 
-  - except we can't see the capacity, so we have a gap here...
+func isRetryable(err error) bool {
+	switch e := err.(type) {
+	case *backoffError:
+		return true
+	case *errors.RequestError:
+		return e.Retryable
+	}
+	return true
+}
 
-  - query is not thread-safe, but is a private method and is only called internally in one place
+// objectError wraps a failure to read action data from object storage,
+// which is only worth retrying if the failure may be transient
+func objectError(err error) error {
+	return errors.NewRequestError(err, s3.IsRetryable(err))
+}
 
-  - Bad example code:
-    queryLimit := 0
-    for {
-    // This is dumb, we only need to
-    queryLimit = Min(maxConLimiter.Tokens(), maxConcurrency - currentActions)
-    if queryLmit >= 1 {
-    break
-    }
-    time.Sleep(100 * time.Milisecond)
-    }
+// retryAfter returns the seconds the action asked to wait before a retry, if any
+func retryAfter(err error) int {
+	if e, ok := err.(*errors.RequestError); ok {
+		return e.RetryAfter
+	}
+	return 0
+}
 
-    err := Limiter.WaitN(ctx, queryLimit)
+// backoffSeconds returns the seconds to wait before retrying the failed
+// action, or false if its retries are exhausted. The action may ask for a
+// different wait, which is capped at the backoff max, as it may come from
+// a remote service.
+func backoffSeconds(backoff *config.HandlerBackoff, retries int, err error) (int, bool) {
+	seconds, ok := backoff.RetrySeconds(retries)
+	if !ok {
+		return 0, false
+	}
 
-MAYBE BETTER IDEA:
+	if after := retryAfter(err); after > 0 {
+		seconds = min(after, backoff.Max)
+	}
 
-We have a "semaphore" and a token bucket. The semaphore needs to return us
-the max number of "resources" available, same with the bucket and number of
-tokens, but we block on either until that number is at least 1. Then we
-take the min of those values and request that allocation from each.
+	return seconds, true
+}
 
-See the following resources:
-  - https://cs.opensource.google/go/x/sync/+/refs/tags/v0.3.0:semaphore/semaphore.go
-  - https://cs.opensource.google/go/x/time/+/master:rate/rate.go
+type HandlerClient interface {
+	HandleAction(ctx context.Context, conn db.Conn, thread *db.Thread) error
+}
 
-Can we combine the bucket and semaphore into a single object? Like the
-limit is adjusted based on the number of reserved, i.e., limit =
-max_limit - allocated?  Like a token bucket that you have to release as
-well as request? <-- YES, THIS
-*/
+type Handler struct {
+	name       string
+	isNotified chan nothing
+	conf       *config.Handler
+	settings   *config.ConductorSettings
+	client     HandlerClient
+	limiter    *limiter
+	debouncer  *debouncer
+	retries    *retryScheduler
+	pool       *workerPool
+
+	// actions being processed, which we must not fetch again
+	mu       sync.Mutex
+	inFlight map[uuid.UUID]nothing
+}
 
 func (h *Handler) GetName() string {
 	return h.name
 }
 
 func (h *Handler) Notify() {
-	// TODO: batch requests until either count or timer reaches threshold before notifying
-	h.NotifyNow()
+	h.debouncer.Notify()
 }
 
+// NotifyNow bypasses any debounce, for when we know we must query
 func (h *Handler) NotifyNow() {
 	select {
 	case h.isNotified <- nothing{}:
@@ -170,7 +188,94 @@ func (h *Handler) NotifyNow() {
 }
 
 func (h *Handler) query(ctx context.Context, conn db.Conn, limit int) ([]*db.Thread, error) {
-	return db.GetProcessableThreads(ctx, conn, h.name, limit, []uuid.UUID{})
+	h.mu.Lock()
+	ignored := make([]uuid.UUID, 0, len(h.inFlight))
+	for actionUuid := range h.inFlight {
+		ignored = append(ignored, actionUuid)
+	}
+	h.mu.Unlock()
+
+	threads, err := db.GetProcessableThreads(ctx, conn, h.name, limit, ignored)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, thread := range threads {
+		h.inFlight[thread.Uuid] = nothing{}
+	}
+
+	return threads, nil
+}
+
+func (h *Handler) done(thread *db.Thread) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.inFlight, thread.Uuid)
+}
+
+// clearInFlight forgets all in-flight threads, for when their session ends
+func (h *Handler) clearInFlight() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight = map[uuid.UUID]nothing{}
+}
+
+// handlerSession holds the connections for a handler database session
+type handlerSession struct {
+	// conn is used for queries, so it holds the thread locks, and is shared
+	// by all in-process actions to release them
+	conn *db.SyncConn
+	// pool provides the in-process actions their transactions
+	pool *pgxpool.Pool
+	// actionCtx is not cancelled with the session, so in-process actions
+	// can finish and record their results as it ends
+	actionCtx context.Context
+	// in-process actions, which must finish before the session ends
+	wg sync.WaitGroup
+}
+
+// drainTimeout bounds how long in-process actions may keep running once
+// their session has ended, after which they are cancelled
+var drainTimeout = 30 * time.Second
+
+// drain waits for the in-process actions of the session to finish,
+// cancelling them if they do not do so within the drain timeout
+func (sess *handlerSession) drain(cancel context.CancelFunc) {
+	drained := make(chan struct{})
+	go func() {
+		sess.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(drainTimeout):
+		log.Printf("in-process actions did not finish within %s, cancelling them", drainTimeout)
+		cancel()
+		<-drained
+	}
+}
+
+func (h *Handler) process(ctx context.Context, sess *handlerSession, thread *db.Thread) {
+	defer h.done(thread)
+	// TODO: need a test to verify we don't leak locks
+	defer thread.Unlock(ctx, sess.conn)
+
+	err := h.client.HandleAction(ctx, sess.pool, thread)
+	// only transient failures should slow us down, and actions cancelled
+	// with their session did not fail due to the remote
+	h.limiter.Release(err == nil || !isRetryable(err) || ctx.Err() != nil)
+	if be, ok := err.(*backoffError); ok {
+		// wake up to process the thread once the backoff is due
+		h.retries.Add(be.retryAt)
+	}
+	if err != nil {
+		log.Printf("handler %s: failed to process thread %s: %s", h.name, thread.Uuid, err)
+		return
+	}
+	log.Printf("handler %s: successfully processed thread %s", h.name, thread.Uuid)
 }
 
 func (h *Handler) poller(ctx context.Context) {
@@ -179,13 +284,13 @@ func (h *Handler) poller(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(pollInterval):
+		case <-time.After(h.settings.PollInterval):
 			h.NotifyNow()
 		}
 	}
 }
 
-func (h *Handler) Run(ctx context.Context, conn db.Conn) error {
+func (h *Handler) Run(ctx context.Context, sess *handlerSession) error {
 	// escape hatch when context is done
 	select {
 	case <-ctx.Done():
@@ -199,7 +304,15 @@ func (h *Handler) Run(ctx context.Context, conn db.Conn) error {
 	case <-h.isNotified:
 	}
 
-	threads, err := h.query(ctx, conn, batchSize)
+	// we only fetch as many threads as we have capacity to process
+	limit, err := h.limiter.Acquire(ctx, h.settings.BatchSize)
+	if err != nil {
+		// only errors if the context is done
+		return nil
+	}
+
+	threads, err := h.query(ctx, sess.conn, limit)
+	h.limiter.Return(limit - len(threads))
 	if err != nil {
 		return err
 	}
@@ -207,60 +320,83 @@ func (h *Handler) Run(ctx context.Context, conn db.Conn) error {
 	log.Printf("handler %s: got %d threads", h.name, len(threads))
 
 	// TODO: test case for this condition
-	if len(threads) == batchSize {
+	if len(threads) == limit {
 		// if we got as many records as we asked for then we suspect we have
 		// more records to process and we notify so we'll immediately query again
 		h.NotifyNow()
 	}
 
-	// We could handle the threads in a goroutine to unblock and increase
-	// throughput. But then we have to track all actionUuids using this
-	// connection, and ensure they are filtered from queries. The expense of
-	// queries then increases, and at a increasing volumes will actually begin
-	// to reduce throughput.
-	//
-	// Perhaps the best solution is ultimately to have a worker pool that can
-	// do the heavy lifiting for handlers. That could possibly be a worker pool
-	// per handler, to prevent other handlers being blocked, or maybe it is a
-	// global pool to keep load more predicatable. Either way, such a model
-	// would offer a means to process a block of records to completion in a
-	// blocking fashion, but allow multiple blocks to execute in parallel to
-	// help mitigate the impact of slow requests.
-	var wg sync.WaitGroup
-	for _, thread := range threads {
+	// Threads are processed by the worker pool so we can continue querying
+	// while they are in process. The limiter ensures we never have more in
+	// process than allowed, and the in-flight threads are excluded from
+	// subsequent queries so we don't pick them up twice.
+	for idx, thread := range threads {
 		thread := thread
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := h.client.HandleAction(ctx, conn, thread)
-			if err != nil {
-				log.Printf("handler %s: failed to process thread %s: %s", h.name, thread.Uuid, err)
-				return
+		sess.wg.Add(1)
+		err := h.pool.Submit(ctx, func() {
+			defer sess.wg.Done()
+			h.process(sess.actionCtx, sess, thread)
+		})
+		if err != nil {
+			sess.wg.Done()
+			// only errors if the context is done
+			for _, thread := range threads[idx:] {
+				h.done(thread)
 			}
-			log.Printf("handler %s: successfully processed thread %s", h.name, thread.Uuid)
-		}()
+			h.limiter.Return(len(threads) - idx)
+			return nil
+		}
 	}
 
-	wg.Wait()
 	return nil
 }
 
-func (h *Handler) Start(ctx context.Context, dbConf *db.ConnectConfig) error {
-	// TODO: need some way to re-enter this, say when we get a db connection error or something
-	//       wait.Until maybe?
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+// session connects to the database and runs the handler until the
+// connection fails or the context is done. In-process actions are drained
+// before it returns, as their thread locks are lost with the connection,
+// and they must not overlap a new session. They are not cancelled unless
+// they fail to drain in time, as cancelling an action whose request has
+// been made would lose its result, and it would be run again.
+func (h *Handler) session(ctx context.Context, dbConf *db.ConnectConfig) error {
+	conn, err := dbConf.Connect(ctx)
+	if err != nil {
+		return err
+	}
 
-	// force polling on start
-	h.NotifyNow()
+	// the connection is shared by the query loop and all in-process actions,
+	// as thread locks are held by the session that queried them
+	sconn := db.NewSyncConn(conn)
+	defer sconn.Close(context.Background())
 
-	conn, err := dbConf.Connect(ctx)
+	// actions need no more connections than we have workers
+	pool, err := (&db.PoolConfig{
+		ConnectConfig: *dbConf,
+		MaxConns:      int32(h.pool.size),
+	}).Connect(ctx)
 	if err != nil {
 		return err
 	}
-	defer conn.Close(ctx)
+	defer pool.Close()
 
-	go h.poller(ctx)
+	actionCtx, cancelActions := context.WithCancel(context.WithoutCancel(ctx))
+	sess := &handlerSession{conn: sconn, pool: pool, actionCtx: actionCtx}
+
+	// deferred last, so it runs before the connections are closed
+	defer func() {
+		sess.drain(cancelActions)
+		cancelActions()
+		h.clearInFlight()
+	}()
+
+	// schedule wakeups for any threads already in backoff
+	retryTimes, err := db.GetRetryTimes(ctx, sconn, h.name)
+	if err != nil {
+		return err
+	}
+	h.retries.Add(retryTimes...)
+
+	// force polling on (re)connect, as we may have missed notifications
+	h.NotifyNow()
 
 	for {
 		select {
@@ -269,9 +405,41 @@ func (h *Handler) Start(ctx context.Context, dbConf *db.ConnectConfig) error {
 		default:
 		}
 
-		err = h.Run(ctx, conn)
+		err = h.Run(ctx, sess)
 		if err != nil {
 			return err
 		}
 	}
 }
+
+func (h *Handler) Start(ctx context.Context, dbConf *db.ConnectConfig) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go h.poller(ctx)
+	go h.retries.run(ctx, h.NotifyNow)
+
+	attempt := 0
+	for {
+		started := time.Now()
+		err := h.session(ctx, dbConf)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// a session that lasted a while was healthy, so we start over
+		if time.Since(started) > db.MaxReconnectBackoff {
+			attempt = 0
+		}
+
+		backoff := db.ReconnectBackoff(attempt)
+		attempt++
+		log.Printf("handler %s: database session failed, reconnecting in %s: %s", h.name, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package conductor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/errors"
+)
+
+func Test_ObjectError(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"not found", minio.ErrorResponse{Code: "NoSuchKey", StatusCode: 404}, false},
+		{"access denied", minio.ErrorResponse{Code: "AccessDenied", StatusCode: 403}, false},
+		{"server error", minio.ErrorResponse{Code: "InternalError"}, true},
+		{"unavailable", minio.ErrorResponse{StatusCode: 503}, true},
+		{"throttled", minio.ErrorResponse{Code: "SlowDown", StatusCode: 503}, true},
+		{"network error", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"truncated", fmt.Errorf("failed to read: %w", io.ErrUnexpectedEOF), true},
+		{"invalid json", &json.SyntaxError{Offset: 1}, false},
+		{"other error", fmt.Errorf("something went wrong"), false},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				if retryable := isRetryable(objectError(test.err)); retryable != test.retryable {
+					t.Fatalf("expected retryable to be %v, got %v", test.retryable, retryable)
+				}
+			},
+		)
+	}
+}
+
+func Test_BackoffSeconds(t *testing.T) {
+	backoff := config.NewHandlerBackoff()
+
+	retryAfter := func(seconds int) error {
+		err := errors.NewRequestError(fmt.Errorf("slow down"), true)
+		err.RetryAfter = seconds
+		return err
+	}
+
+	for _, test := range []struct {
+		name     string
+		retries  int
+		err      error
+		expected int
+		ok       bool
+	}{
+		{"backoff", 1, fmt.Errorf("failed"), 120, true},
+		{"retry after", 1, retryAfter(30), 30, true},
+		{"retry after capped", 1, retryAfter(31536000), backoff.Max, true},
+		{"exhausted", backoff.Retries, retryAfter(30), 0, false},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				seconds, ok := backoffSeconds(backoff, test.retries, test.err)
+				if seconds != test.expected || ok != test.ok {
+					t.Fatalf("expected (%d, %v), got (%d, %v)", test.expected, test.ok, seconds, ok)
+				}
+			},
+		)
+	}
+}
+
+func Test_SessionDrain(t *testing.T) {
+	drainTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { drainTimeout = 30 * time.Second })
+
+	t.Run(
+		"finishes without cancel",
+		func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sess := &handlerSession{actionCtx: ctx}
+
+			sess.wg.Add(1)
+			go func() {
+				defer sess.wg.Done()
+				time.Sleep(20 * time.Millisecond)
+			}()
+
+			sess.drain(cancel)
+			if ctx.Err() != nil {
+				t.Fatal("expected an action that finished in time not to be cancelled")
+			}
+		},
+	)
+
+	t.Run(
+		"cancels after timeout",
+		func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sess := &handlerSession{actionCtx: ctx}
+
+			sess.wg.Add(1)
+			go func() {
+				defer sess.wg.Done()
+				<-ctx.Done()
+			}()
+
+			sess.drain(cancel)
+			if ctx.Err() == nil {
+				t.Fatal("expected a hung action to be cancelled")
+			}
+		},
+	)
+}
@@ -2,24 +2,71 @@ package conductor
 
 import (
 	"context"
-	"io"
 	"log"
+	"time"
 
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/element84/swoop-go/pkg/config"
 	"github.com/element84/swoop-go/pkg/config/http"
 	"github.com/element84/swoop-go/pkg/db"
 	"github.com/element84/swoop-go/pkg/errors"
 	"github.com/element84/swoop-go/pkg/s3"
+	"github.com/element84/swoop-go/pkg/secrets"
 )
 
 type httpClient struct {
 	*http.Client
 	s3      *s3.SwoopS3
+	secrets secrets.Secrets
+	backoff *config.HandlerBackoff
 	isAsync bool
+	// async clients give the remote service a webhook to report results
+	webhook *webhook
+}
+
+func newSyncHttpClient(
+	client *http.Client,
+	secrets secrets.Secrets,
+	backoff *config.HandlerBackoff,
+	s3 *s3.SwoopS3,
+) *httpClient {
+	return &httpClient{client, s3, secrets, backoff, false, nil}
 }
 
-// TODO: we'll need to pass the secrets object on through here
-func newSyncHttpClient(client *http.Client, s3 *s3.SwoopS3) *httpClient {
-	return &httpClient{client, s3, false}
+func newAsyncHttpClient(
+	client *http.Client,
+	secrets secrets.Secrets,
+	backoff *config.HandlerBackoff,
+	s3 *s3.SwoopS3,
+	webhook *webhook,
+) *httpClient {
+	return &httpClient{client, s3, secrets, backoff, true, webhook}
+}
+
+// outputRetries and outputBackoff bound how long we keep trying to write
+// an action output before giving up on it
+var (
+	outputRetries = 3
+	outputBackoff = time.Second
+)
+
+// putOutput writes the action output, retrying with backoff if it fails
+func (hc *httpClient) putOutput(ctx context.Context, actionUuid uuid.UUID, output any) error {
+	backoff := outputBackoff
+	for attempt := 0; ; attempt++ {
+		err := hc.s3.PutCallbackOutput(ctx, actionUuid, output)
+		if err == nil || attempt >= outputRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
 
 func (hc *httpClient) HandleAction(ctx context.Context, conn db.Conn, thread *db.Thread) error {
@@ -30,69 +77,61 @@ func (hc *httpClient) HandleAction(ctx context.Context, conn db.Conn, thread *db
 		//   -> or look at action type and use that to parameterize the prefix?
 		params, err := hc.s3.GetCallbackParams(ctx, thread.Uuid)
 		if err != nil {
-			return errors.NewRequestError(err, false)
+			return objectError(err)
 		}
 
-		request, err := hc.NewRequest(map[string]any{
+		secretData := hc.secrets.Data(ctx)
+		data := map[string]any{
 			"uuid":       thread.Uuid,
 			"parameters": params,
-		})
+			"secrets":    secretData,
+		}
+		if hc.webhook != nil {
+			data["callback"] = hc.webhook.CallbackData(thread.Uuid)
+		}
+
+		request, err := hc.NewRequest(data)
 		if err != nil {
 			return errors.NewRequestError(err, false)
 		}
 
 		resp, err := hc.MakeRequest(ctx, request)
 
-		readBody := func() (*string, error) {
-			reader, err := request.GetBody()
-			if err != nil {
-				return nil, err
-			}
+		secretValues := make([]string, 0, len(secretData)+1)
+		for _, val := range secretData {
+			secretValues = append(secretValues, val)
+		}
+		if hc.webhook != nil {
+			secretValues = append(secretValues, hc.webhook.Token(thread.Uuid))
+		}
 
-			b, err := io.ReadAll(reader)
-			if err != nil {
-				return nil, err
+		record := hc.Log.Record(request.Request, resp, secretValues)
+		if record != nil {
+			_err := hc.s3.PutCallbackHttp(ctx, thread.Uuid, record)
+			if _err != nil {
+				log.Printf("failed to write http data: %s", _err)
 			}
+		}
 
-			body := string(b)
-
-			return &body, nil
+		if err != nil {
+			return err
 		}
 
-		logRequest := func() error {
-			body, err := readBody()
+		// The request has been made, so the action must not be retried
+		// just because we failed to store its output.
+		output := hc.Output.Extract(resp)
+		if output != nil {
+			err = hc.putOutput(ctx, thread.Uuid, output)
 			if err != nil {
-				log.Printf("failed to read request body: %s", err)
+				log.Printf(
+					"action '%s' succeeded but failed to write its output: %s",
+					thread.Uuid,
+					err,
+				)
 			}
-
-			return hc.s3.PutCallbackHttp(
-				ctx,
-				thread.Uuid,
-				map[string]any{
-					"request": map[string]any{
-						"method": request.Method,
-						"url":    request.URL.String(),
-						"proto":  request.Proto,
-						"header": request.Header,
-						"body":   body,
-						"host":   request.Host,
-					},
-					"response": resp,
-				},
-			)
-		}
-
-		// TODO: LOGGING LIKE THIS COULD LEAK SECRETS!!!
-		// We should consider having a way to:
-		//   - toggle logging on/off (off by default)
-		//   - redact certain info (sounds hard/impossible)
-		//   - ???
-		_err := logRequest()
-		if _err != nil {
-			log.Printf("failed to write http data: %s", _err)
 		}
 
-		return err
+		return nil
 	}
-	return HandleActionWrapper(ctx, conn, thread, hc.isAsync, handleFn)
+	return HandleActionWrapper(ctx, conn, thread, hc.isAsync, hc.backoff, handleFn)
 }
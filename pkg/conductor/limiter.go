@@ -0,0 +1,202 @@
+package conductor
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// how often the limiter rate is adjusted per action outcomes
+	limiterAdjustInterval = 10 * time.Second
+	// floor for the adaptive rate, in actions per second
+	limiterMinRate = 1.0 / 60
+)
+
+// limiter is a token bucket that must be released as well as requested.
+//
+// Starting an action takes a token from the bucket and a slot of the
+// available concurrency. Tokens are refilled at the current rate, but slots
+// are only returned when the action is released. Callers block on whichever
+// is limiting until at least one action can be started, then are given the
+// min of the available tokens and slots.
+//
+// The rate adapts to action outcomes: every limiterAdjustInterval the rate is
+// halved (down to limiterMinRate) if failures outnumber successes, otherwise
+// it is doubled (up to the configured rate) if successes outnumber failures.
+//
+// A configured rate of zero means the rate is unlimited and only the
+// concurrency is enforced.
+//
+// Acquire is not intended for concurrent use, as only the handler query loop
+// reserves capacity. Return and Release are safe to call from any goroutine.
+type limiter struct {
+	mu sync.Mutex
+
+	maxRate float64
+	rate    float64
+	tokens  float64
+	last    time.Time
+
+	maxConcurrency int
+	inFlight       int
+
+	successes  int
+	failures   int
+	lastAdjust time.Time
+
+	released chan nothing
+}
+
+func newLimiter(rate float64, maxConcurrency int) *limiter {
+	now := time.Now()
+	l := &limiter{
+		maxRate:        rate,
+		rate:           rate,
+		last:           now,
+		maxConcurrency: maxConcurrency,
+		lastAdjust:     now,
+		released:       make(chan nothing, 1),
+	}
+	l.tokens = l.burst()
+	return l
+}
+
+func (l *limiter) unlimited() bool {
+	return l.maxRate == 0
+}
+
+func (l *limiter) burst() float64 {
+	return math.Max(math.Ceil(l.rate), 1)
+}
+
+// advance refills the bucket and adjusts the rate if due; must hold l.mu
+func (l *limiter) advance(now time.Time) {
+	if l.unlimited() {
+		return
+	}
+
+	l.tokens = math.Min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.burst())
+	l.last = now
+
+	if now.Sub(l.lastAdjust) < limiterAdjustInterval {
+		return
+	}
+
+	if l.failures > l.successes {
+		l.rate = math.Max(l.rate/2, limiterMinRate)
+		l.tokens = math.Min(l.tokens, l.burst())
+	} else if l.successes > l.failures {
+		l.rate = math.Min(l.rate*2, l.maxRate)
+	}
+
+	l.successes = 0
+	l.failures = 0
+	l.lastAdjust = now
+}
+
+// reserve takes up to max tokens and slots. If nothing can be reserved it
+// returns the time until the next token is available, or zero if we are
+// waiting on a slot to be released.
+func (l *limiter) reserve(now time.Time, max int) (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(now)
+
+	slots := l.maxConcurrency - l.inFlight
+	n := min(slots, max)
+	if !l.unlimited() {
+		n = min(n, int(l.tokens))
+	}
+
+	if n >= 1 {
+		l.inFlight += n
+		if !l.unlimited() {
+			l.tokens -= float64(n)
+		}
+		return n, 0
+	}
+
+	if slots < 1 {
+		return 0, 0
+	}
+
+	return 0, time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Acquire blocks until at least one action can be started, then reserves
+// as many as are available, up to max. Reservations not used must be given
+// back via Return, and those used via Release once the action is complete.
+func (l *limiter) Acquire(ctx context.Context, max int) (int, error) {
+	for {
+		n, wait := l.reserve(time.Now(), max)
+		if n > 0 {
+			return n, nil
+		}
+
+		var (
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-l.released:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// Return gives back n unused reservations.
+func (l *limiter) Return(n int) {
+	if n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight -= n
+	if !l.unlimited() {
+		l.tokens = math.Min(l.tokens+float64(n), l.burst())
+	}
+	l.signal()
+}
+
+// Release frees the slot held by a completed action, recording
+// whether it succeeded for the next rate adjustment.
+func (l *limiter) Release(success bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if success {
+		l.successes++
+	} else {
+		l.failures++
+	}
+	l.signal()
+}
+
+// signal wakes a blocked Acquire; must hold l.mu
+func (l *limiter) signal() {
+	select {
+	case l.released <- nothing{}:
+	default:
+		// already signaled, nothing to do
+	}
+}
@@ -0,0 +1,116 @@
+package conductor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_LimiterConcurrency(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	l := newLimiter(0, 3)
+
+	n, err := l.Acquire(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to acquire: %s", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected to acquire 3, got %d", n)
+	}
+
+	l.Return(1)
+
+	n, err = l.Acquire(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to acquire: %s", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected to acquire 1 after return, got %d", n)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l.Release(true)
+		l.Release(true)
+	}()
+
+	n, err = l.Acquire(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to acquire: %s", err)
+	}
+	if n < 1 {
+		t.Fatalf("expected to acquire at least 1 after release, got %d", n)
+	}
+
+	_ctx, _cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer _cancel()
+	l = newLimiter(0, 1)
+	_, _ = l.Acquire(_ctx, 1)
+	_, err = l.Acquire(_ctx, 1)
+	if err == nil {
+		t.Fatal("acquire should have blocked until the context was done")
+	}
+}
+
+func Test_LimiterRate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	l := newLimiter(20, 100)
+
+	n, err := l.Acquire(ctx, 100)
+	if err != nil {
+		t.Fatalf("failed to acquire: %s", err)
+	}
+	if n != 20 {
+		t.Fatalf("expected to acquire the burst of 20, got %d", n)
+	}
+
+	start := time.Now()
+	n, err = l.Acquire(ctx, 100)
+	if err != nil {
+		t.Fatalf("failed to acquire: %s", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected to acquire 1 once refilled, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Fatalf("acquire should have waited for a token, only waited %s", elapsed)
+	}
+}
+
+func Test_LimiterAdjust(t *testing.T) {
+	l := newLimiter(8, 100)
+	now := time.Now()
+
+	for i := 0; i < 4; i++ {
+		l.reserve(now, 1)
+		l.Release(false)
+	}
+
+	now = now.Add(limiterAdjustInterval)
+	l.reserve(now, 1)
+	if l.rate != 4 {
+		t.Fatalf("expected rate to halve to 4 after failures, got %v", l.rate)
+	}
+
+	l.Release(true)
+	l.Release(true)
+
+	now = now.Add(limiterAdjustInterval)
+	l.reserve(now, 1)
+	if l.rate != 8 {
+		t.Fatalf("expected rate to double to 8 after successes, got %v", l.rate)
+	}
+
+	l.Release(true)
+	l.Release(true)
+
+	now = now.Add(limiterAdjustInterval)
+	l.reserve(now, 1)
+	if l.rate != 8 {
+		t.Fatalf("expected rate to not exceed configured 8, got %v", l.rate)
+	}
+}
@@ -0,0 +1,48 @@
+package conductor
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/db"
+	"github.com/element84/swoop-go/pkg/errors"
+)
+
+// noopClient marks actions successful without doing anything, optionally
+// after a delay or with injected failures, for testing and load testing.
+type noopClient struct {
+	conf    *config.NoopConf
+	backoff *config.HandlerBackoff
+}
+
+func newNoopClient(conf *config.NoopConf, backoff *config.HandlerBackoff) *noopClient {
+	if conf == nil {
+		conf = &config.NoopConf{}
+	}
+	return &noopClient{conf, backoff}
+}
+
+func (nc *noopClient) act(ctx context.Context) error {
+	if nc.conf.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(nc.conf.Delay):
+		}
+	}
+
+	if rand.Float64() < nc.conf.FailureRatio {
+		return errors.NewRequestError(fmt.Errorf("noop: injected failure"), true)
+	}
+
+	return nil
+}
+
+func (nc *noopClient) HandleAction(ctx context.Context, conn db.Conn, thread *db.Thread) error {
+	return HandleActionWrapper(ctx, conn, thread, false, nc.backoff, func() error {
+		return nc.act(ctx)
+	})
+}
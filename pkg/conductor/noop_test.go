@@ -0,0 +1,36 @@
+package conductor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+func Test_NoopClient(t *testing.T) {
+	ctx := context.Background()
+
+	err := newNoopClient(nil, nil).act(ctx)
+	if err != nil {
+		t.Fatalf("noop without config should succeed: %s", err)
+	}
+
+	err = newNoopClient(&config.NoopConf{FailureRatio: 1}, nil).act(ctx)
+	if err == nil {
+		t.Fatal("noop with failure ratio of 1 should fail")
+	}
+	if !isRetryable(err) {
+		t.Fatal("injected failures should be retryable")
+	}
+
+	delay := 50 * time.Millisecond
+	start := time.Now()
+	err = newNoopClient(&config.NoopConf{Delay: delay}, nil).act(ctx)
+	if err != nil {
+		t.Fatalf("noop with delay should succeed: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Fatalf("expected a delay of at least %s, got %s", delay, elapsed)
+	}
+}
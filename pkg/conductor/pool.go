@@ -0,0 +1,53 @@
+package conductor
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool runs submitted jobs on a fixed number of workers. A pool
+// can be dedicated to a single handler or shared between handlers.
+type workerPool struct {
+	size int
+	jobs chan func()
+	once sync.Once
+}
+
+func newWorkerPool(size int) *workerPool {
+	return &workerPool{
+		size: size,
+		jobs: make(chan func()),
+	}
+}
+
+// Start launches the pool workers, which run until ctx is done. Starting an
+// already-started pool does nothing, so shared pools can be started by each
+// of their users.
+func (p *workerPool) Start(ctx context.Context) {
+	p.once.Do(func() {
+		for i := 0; i < p.size; i++ {
+			go p.worker(ctx)
+		}
+	})
+}
+
+func (p *workerPool) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-p.jobs:
+			job()
+		}
+	}
+}
+
+// Submit blocks until a worker accepts the job or ctx is done.
+func (p *workerPool) Submit(ctx context.Context, job func()) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case p.jobs <- job:
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package conductor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_WorkerPool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	size := 3
+	pool := newWorkerPool(size)
+	pool.Start(ctx)
+	// starting again should not add workers
+	pool.Start(ctx)
+
+	var (
+		mu      sync.Mutex
+		running int
+		peak    int
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		err := pool.Submit(ctx, func() {
+			defer wg.Done()
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("failed to submit job: %s", err)
+		}
+	}
+
+	wg.Wait()
+
+	if peak != size {
+		t.Fatalf("expected peak concurrency of %d, got %d", size, peak)
+	}
+}
+
+func Test_WorkerPoolSubmitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// pool never started, so submit can only return by the context
+	err := newWorkerPool(1).Submit(ctx, func() {})
+	if err == nil {
+		t.Fatal("submit should have errored per the context being done")
+	}
+}
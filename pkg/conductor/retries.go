@@ -0,0 +1,111 @@
+package conductor
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// small delay added to retry times before waking, so we don't
+// query before the database considers the thread processable
+const retryWakeDelay = 1 * time.Second
+
+type retryTimes []time.Time
+
+func (rt retryTimes) Len() int           { return len(rt) }
+func (rt retryTimes) Less(i, j int) bool { return rt[i].Before(rt[j]) }
+func (rt retryTimes) Swap(i, j int)      { rt[i], rt[j] = rt[j], rt[i] }
+
+func (rt *retryTimes) Push(x any) {
+	*rt = append(*rt, x.(time.Time))
+}
+
+func (rt *retryTimes) Pop() any {
+	old := *rt
+	n := len(old)
+	t := old[n-1]
+	*rt = old[:n-1]
+	return t
+}
+
+// retryScheduler keeps a min heap of times at which backed-off actions
+// become processable, and wakes the handler when the earliest is due.
+type retryScheduler struct {
+	mu      sync.Mutex
+	times   retryTimes
+	updated chan nothing
+}
+
+func newRetryScheduler() *retryScheduler {
+	return &retryScheduler{
+		times:   retryTimes{},
+		updated: make(chan nothing, 1),
+	}
+}
+
+func (rs *retryScheduler) Add(times ...time.Time) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	for _, t := range times {
+		heap.Push(&rs.times, t.Add(retryWakeDelay))
+	}
+
+	select {
+	case rs.updated <- nothing{}:
+	default:
+		// update already pending, nothing to do
+	}
+}
+
+// popDue removes all times not after now, returning whether any were due,
+// along with the next time still pending, if any.
+func (rs *retryScheduler) popDue(now time.Time) (bool, time.Time, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	due := false
+	for rs.times.Len() > 0 && !rs.times[0].After(now) {
+		heap.Pop(&rs.times)
+		due = true
+	}
+
+	if rs.times.Len() == 0 {
+		return due, time.Time{}, false
+	}
+
+	return due, rs.times[0], true
+}
+
+func (rs *retryScheduler) run(ctx context.Context, notify func()) {
+	for {
+		due, next, pending := rs.popDue(time.Now())
+		if due {
+			notify()
+		}
+
+		var (
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		if pending {
+			timer = time.NewTimer(time.Until(next))
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-rs.updated:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
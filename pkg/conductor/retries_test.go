@@ -0,0 +1,66 @@
+package conductor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_RetrySchedulerPopDue(t *testing.T) {
+	now := time.Now()
+	rs := newRetryScheduler()
+
+	rs.Add(
+		now.Add(30*time.Second),
+		now.Add(-10*time.Second),
+		now.Add(10*time.Second),
+		now.Add(-5*time.Second),
+	)
+
+	due, next, pending := rs.popDue(now)
+	if !due {
+		t.Fatal("expected past retry times to be due")
+	}
+	if !pending {
+		t.Fatal("expected future retry times to be pending")
+	}
+
+	expected := now.Add(10 * time.Second).Add(retryWakeDelay)
+	if !next.Equal(expected) {
+		t.Fatalf("expected next retry at '%s', got '%s'", expected, next)
+	}
+
+	due, _, _ = rs.popDue(now)
+	if due {
+		t.Fatal("no retry times should be due after popping")
+	}
+
+	due, _, pending = rs.popDue(now.Add(time.Minute))
+	if !due || pending {
+		t.Fatal("expected all retry times to be due and none pending")
+	}
+}
+
+func Test_RetrySchedulerRun(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notified := make(chan nothing, 1)
+	notify := func() {
+		select {
+		case notified <- nothing{}:
+		default:
+		}
+	}
+
+	rs := newRetryScheduler()
+	go rs.run(ctx, notify)
+
+	rs.Add(time.Now().Add(-retryWakeDelay).Add(100 * time.Millisecond))
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for retry notification")
+	case <-notified:
+	}
+}
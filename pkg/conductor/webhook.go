@@ -0,0 +1,228 @@
+package conductor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/db"
+	"github.com/element84/swoop-go/pkg/states"
+)
+
+const (
+	webhookActionsPath = "/actions/"
+	// max size of a webhook request body
+	webhookMaxBody = 1 << 20
+)
+
+// webhook provides the callback url and per-action token given to remote
+// services by asynchttp handlers, and receives their results. Tokens are an
+// HMAC of the action uuid, so they need not be stored.
+type webhook struct {
+	conf     *config.WebhookSettings
+	secret   []byte
+	pool     *pgxpool.Pool
+	handlers map[string]nothing
+}
+
+func newWebhook(conf *config.WebhookSettings, secret string) (*webhook, error) {
+	if conf == nil {
+		return nil, errors.New("asynchttp handlers require conductor webhook settings")
+	}
+
+	if secret == "" {
+		return nil, errors.New("asynchttp handlers require a webhook secret")
+	}
+
+	return &webhook{
+		conf:     conf,
+		secret:   []byte(secret),
+		handlers: map[string]nothing{},
+	}, nil
+}
+
+func (wh *webhook) Token(actionUuid uuid.UUID) string {
+	mac := hmac.New(sha256.New, wh.secret)
+	mac.Write(actionUuid.Bytes())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (wh *webhook) verify(actionUuid uuid.UUID, token string) bool {
+	return hmac.Equal([]byte(wh.Token(actionUuid)), []byte(token))
+}
+
+func (wh *webhook) Url(actionUuid uuid.UUID) string {
+	return strings.TrimSuffix(wh.conf.ExternalUrl, "/") + webhookActionsPath + actionUuid.String()
+}
+
+// CallbackData is the template data for an action's callback
+func (wh *webhook) CallbackData(actionUuid uuid.UUID) map[string]any {
+	return map[string]any{
+		"url":   wh.Url(actionUuid),
+		"token": wh.Token(actionUuid),
+	}
+}
+
+// addHandler allows results for actions of the named handler
+func (wh *webhook) addHandler(name string) {
+	wh.handlers[name] = nothing{}
+}
+
+type webhookResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+func (wr *webhookResult) state() (states.ActionState, error) {
+	status, err := states.Parse(wr.Status)
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case states.Successful, states.Failed:
+		return status, nil
+	}
+
+	return "", fmt.Errorf("status must be successful or failed, got '%s'", wr.Status)
+}
+
+// respond writes only the status text, as error details may reveal
+// internals to the caller, and should be logged instead
+func respond(w http.ResponseWriter, code int) {
+	http.Error(w, http.StatusText(code), code)
+}
+
+func (wh *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		respond(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	actionUuid, err := uuid.FromString(strings.TrimPrefix(r.URL.Path, webhookActionsPath))
+	if err != nil || !strings.HasPrefix(r.URL.Path, webhookActionsPath) {
+		respond(w, http.StatusNotFound)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !wh.verify(actionUuid, token) {
+		respond(w, http.StatusUnauthorized)
+		return
+	}
+
+	result := &webhookResult{}
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, webhookMaxBody)).Decode(result)
+	if err != nil {
+		log.Printf("webhook: invalid body for action %s: %s", actionUuid, err)
+		respond(w, http.StatusBadRequest)
+		return
+	}
+
+	status, err := result.state()
+	if err != nil {
+		log.Printf("webhook: invalid result for action %s: %s", actionUuid, err)
+		respond(w, http.StatusBadRequest)
+		return
+	}
+
+	code, err := wh.complete(r.Context(), actionUuid, status, result.Error)
+	if err != nil {
+		log.Printf("webhook: failed to complete action %s: %s", actionUuid, err)
+		respond(w, code)
+		return
+	}
+
+	log.Printf("webhook: completed action %s as %s", actionUuid, status)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// complete inserts the final event for the action, returning the http
+// status code to use if it fails
+func (wh *webhook) complete(
+	ctx context.Context,
+	actionUuid uuid.UUID,
+	status states.ActionState,
+	errorMsg string,
+) (int, error) {
+	tx, err := wh.pool.Begin(ctx)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+	defer tx.Rollback(ctx)
+
+	handlerName, current, err := db.LockThreadStatus(ctx, tx, actionUuid)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound, fmt.Errorf("unknown action")
+	} else if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+
+	if _, ok := wh.handlers[handlerName]; !ok {
+		return http.StatusNotFound, fmt.Errorf("unknown action")
+	}
+
+	// the result may arrive before the conductor has recorded the action as
+	// queued, in which case the remote service should retry
+	if current != states.Queued && current != states.Running {
+		return http.StatusConflict, fmt.Errorf("action is not awaiting a result, status is '%s'", current)
+	}
+
+	err = (&db.Event{
+		ActionUuid: actionUuid,
+		Status:     status,
+		ErrorMsg:   errorMsg,
+	}).Insert(ctx, tx)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+
+	return http.StatusNoContent, nil
+}
+
+// Serve runs the webhook server until ctx is done
+func (wh *webhook) Serve(ctx context.Context, pool *pgxpool.Pool) error {
+	wh.pool = pool
+
+	mux := http.NewServeMux()
+	mux.Handle(webhookActionsPath, wh)
+
+	server := &http.Server{
+		Addr:              wh.conf.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("webhook: listening on %s", wh.conf.Listen)
+	err := server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package conductor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+func Test_WebhookRequiresSecret(t *testing.T) {
+	_, err := newWebhook(&config.WebhookSettings{ExternalUrl: "http://localhost"}, "")
+	if err == nil {
+		t.Fatal("webhook without a secret should error")
+	}
+}
+
+func Test_WebhookCallbackData(t *testing.T) {
+	wh, err := newWebhook(&config.WebhookSettings{ExternalUrl: "https://swoop.test/base/"}, "secret")
+	if err != nil {
+		t.Fatalf("failed to make webhook: %s", err)
+	}
+
+	actionUuid := uuid.Must(uuid.NewV4())
+	data := wh.CallbackData(actionUuid)
+
+	expected := "https://swoop.test/base/actions/" + actionUuid.String()
+	if data["url"] != expected {
+		t.Fatalf("expected url '%s', got '%s'", expected, data["url"])
+	}
+
+	token := data["token"].(string)
+	if !wh.verify(actionUuid, token) {
+		t.Fatal("token should verify for its action")
+	}
+	if wh.verify(uuid.Must(uuid.NewV4()), token) {
+		t.Fatal("token should not verify for another action")
+	}
+
+	other, _ := newWebhook(wh.conf, "other")
+	if other.verify(actionUuid, token) {
+		t.Fatal("token should not verify with another secret")
+	}
+}
+
+func Test_WebhookRejects(t *testing.T) {
+	wh, err := newWebhook(&config.WebhookSettings{ExternalUrl: "http://localhost"}, "secret")
+	if err != nil {
+		t.Fatalf("failed to make webhook: %s", err)
+	}
+
+	actionUuid := uuid.Must(uuid.NewV4())
+	path := webhookActionsPath + actionUuid.String()
+	auth := "Bearer " + wh.Token(actionUuid)
+
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		auth     string
+		body     string
+		expected int
+	}{
+		{"wrong method", http.MethodGet, path, auth, "", http.StatusMethodNotAllowed},
+		{"bad uuid", http.MethodPost, webhookActionsPath + "nope", auth, "", http.StatusNotFound},
+		{"no token", http.MethodPost, path, "", "", http.StatusUnauthorized},
+		{"bad token", http.MethodPost, path, "Bearer nope", "", http.StatusUnauthorized},
+		{"bad body", http.MethodPost, path, auth, "{", http.StatusBadRequest},
+		{"bad status", http.MethodPost, path, auth, `{"status": "running"}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+				if tc.auth != "" {
+					req.Header.Set("Authorization", tc.auth)
+				}
+				rec := httptest.NewRecorder()
+
+				wh.ServeHTTP(rec, req)
+
+				if rec.Code != tc.expected {
+					t.Fatalf("expected status %d, got %d", tc.expected, rec.Code)
+				}
+
+				// no error details should be exposed to the caller
+				body := strings.TrimSpace(rec.Body.String())
+				if body != http.StatusText(tc.expected) {
+					t.Fatalf("expected body '%s', got '%s'", http.StatusText(tc.expected), body)
+				}
+			},
+		)
+	}
+}
@@ -25,6 +25,23 @@ type K8sOptions struct {
 	ConfigOverrides *clientcmd.ConfigOverrides `yaml:"configOverrides"`
 }
 
+// GetConfig returns the k8s client config, resolved per the standard
+// loading rules if no options are set
+func (ko *K8sOptions) GetConfig() clientcmd.ClientConfig {
+	lrs := clientcmd.NewDefaultClientConfigLoadingRules()
+	lrs.DefaultClientConfig = &clientcmd.DefaultClientConfig
+
+	overrides := &clientcmd.ConfigOverrides{}
+	if ko != nil {
+		lrs.ExplicitPath = ko.Kubeconfig
+		if ko.ConfigOverrides != nil {
+			overrides = ko.ConfigOverrides
+		}
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(lrs, overrides)
+}
+
 type ArgoConf struct {
 	InstanceId string      `yaml:"instanceId"`
 	K8sOptions *K8sOptions `yaml:"k8sOptions"`
@@ -32,13 +49,7 @@ type ArgoConf struct {
 }
 
 func (ac *ArgoConf) GetConfig() clientcmd.ClientConfig {
-	lrs := clientcmd.NewDefaultClientConfigLoadingRules()
-	lrs.DefaultClientConfig = &clientcmd.DefaultClientConfig
-	lrs.ExplicitPath = ac.K8sOptions.Kubeconfig
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		lrs,
-		ac.K8sOptions.ConfigOverrides,
-	)
+	return ac.K8sOptions.GetConfig()
 }
 
 func (ac *ArgoConf) GetNamespace() (string, error) {
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+// WorkflowDeletion controls the deletion of workflows once processed. If
+// disabled, processed workflows are kept until removed by other means.
+type WorkflowDeletion struct {
+	Enabled bool `default:"true" yaml:"enabled"`
+	// GracePeriod is how long after processing a workflow is kept
+	GracePeriod time.Duration `default:"5m" yaml:"gracePeriod"`
+}
+
+func (wd *WorkflowDeletion) Validate() error {
+	if wd.GracePeriod < 0 {
+		return fmt.Errorf("workflowDeletion gracePeriod must not be negative, got '%s'", wd.GracePeriod)
+	}
+	return nil
+}
+
+type Caboose struct {
+	// WorkflowResyncPeriod is how often the workflow informer resyncs
+	WorkflowResyncPeriod time.Duration `default:"20m" yaml:"workflowResyncPeriod"`
+	// MaxWorkers is the number of workers processing workflow events
+	MaxWorkers int `default:"4" yaml:"maxWorkers"`
+	// MinBackoff is the delay before the first retry of a failed event
+	MinBackoff time.Duration `default:"2s" yaml:"minBackoff"`
+	// MaxBackoff is the upper bound on the delay between event retries
+	MaxBackoff time.Duration `default:"300s" yaml:"maxBackoff"`
+	// MaxRetries is how many times a failed completion event is retried
+	// before the workflow is dead-lettered
+	MaxRetries int `default:"10" yaml:"maxRetries"`
+	// EventTimeout is the deadline for processing a workflow event
+	EventTimeout time.Duration `default:"5m" yaml:"eventTimeout"`
+	// InstanceId limits the caboose to workflows with a matching argo
+	// instance id; empty matches workflows without an instance id
+	InstanceId string `yaml:"instanceId"`
+	// WorkflowDeletion controls if and when processed workflows are deleted
+	WorkflowDeletion WorkflowDeletion `yaml:"workflowDeletion"`
+	// LegacyNameLookup looks up the names of workflows without a swoop
+	// workflow id label in the database; disable it once no workflows
+	// submitted before the label was added remain, and unlabeled
+	// workflows will be ignored
+	LegacyNameLookup bool `default:"true" yaml:"legacyNameLookup"`
+}
+
+func NewCaboose() *Caboose {
+	c := &Caboose{}
+	defaults.Set(c)
+	return c
+}
+
+func (c *Caboose) Validate() error {
+	if c.WorkflowResyncPeriod < 0 {
+		return fmt.Errorf(
+			"workflowResyncPeriod must not be negative, got '%s'",
+			c.WorkflowResyncPeriod,
+		)
+	}
+
+	if c.MaxWorkers < 1 {
+		return fmt.Errorf("maxWorkers must be at least 1, got '%d'", c.MaxWorkers)
+	}
+
+	if c.MinBackoff <= 0 {
+		return fmt.Errorf("minBackoff must be positive, got '%s'", c.MinBackoff)
+	}
+
+	if c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf(
+			"maxBackoff '%s' must not be less than minBackoff '%s'",
+			c.MaxBackoff,
+			c.MinBackoff,
+		)
+	}
+
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("maxRetries must not be negative, got '%d'", c.MaxRetries)
+	}
+
+	if c.EventTimeout <= 0 {
+		return fmt.Errorf("eventTimeout must be positive, got '%s'", c.EventTimeout)
+	}
+
+	return c.WorkflowDeletion.Validate()
+}
+
+func (c *Caboose) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(c)
+
+	type p Caboose
+
+	err := unmarshal((*p)(c))
+	if err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_CabooseDefaults(t *testing.T) {
+	c := &Caboose{}
+
+	err := yaml.Unmarshal([]byte(`maxWorkers: 8`), c)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	expected := Caboose{
+		WorkflowResyncPeriod: 20 * time.Minute,
+		MaxWorkers:           8,
+		MinBackoff:           2 * time.Second,
+		MaxBackoff:           300 * time.Second,
+		MaxRetries:           10,
+		EventTimeout:         5 * time.Minute,
+		WorkflowDeletion: WorkflowDeletion{
+			Enabled:     true,
+			GracePeriod: 5 * time.Minute,
+		},
+		LegacyNameLookup: true,
+	}
+	if *c != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *c)
+	}
+}
+
+func Test_CabooseBad(t *testing.T) {
+	for _, yml := range []string{
+		"maxWorkers: 0",
+		"minBackoff: 0s",
+		"{minBackoff: 10s, maxBackoff: 5s}",
+		"workflowDeletion: {gracePeriod: -1s}",
+		"eventTimeout: 0s",
+		"maxRetries: -1",
+	} {
+		t.Run(
+			yml,
+			func(t *testing.T) {
+				err := yaml.Unmarshal([]byte(yml), &Caboose{})
+				if err == nil {
+					t.Fatal("expected validation error")
+				}
+			},
+		)
+	}
+}
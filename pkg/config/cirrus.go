@@ -1,5 +1,66 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type CirrusConf struct {
+	SqsUrl string `yaml:"sqsUrl"`
+	// Region defaults per the standard aws config resolution
+	Region string `yaml:"region,omitempty"`
+	// Endpoint overrides the SQS endpoint, such as for a local stand-in
+	Endpoint string `yaml:"endpoint,omitempty"`
+}
+
+func (cc *CirrusConf) Validate() error {
+	if cc.SqsUrl == "" {
+		return errors.New("cirrusConf must define 'sqsUrl'")
+	}
+	return nil
+}
+
+func (cc *CirrusConf) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p CirrusConf
+
+	err := unmarshal((*p)(cc))
+	if err != nil {
+		return err
+	}
+
+	return cc.Validate()
+}
+
 type CirrusWorkflowOpts struct {
 	SfnArn string `yaml:"sfnArn"`
 }
+
+// DecoratePayload adds the process block cirrus needs to run the workflow
+// to the input payload, preserving any process options already present.
+func (cwo *CirrusWorkflowOpts) DecoratePayload(
+	payload any,
+	wfId string,
+	wfUuid uuid.UUID,
+) (map[string]any, error) {
+	p, ok := payload.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cirrus payload must be an object, got '%T'", payload)
+	}
+
+	process, ok := p["process"].(map[string]any)
+	if !ok {
+		process = map[string]any{}
+	}
+
+	process["workflow"] = wfId
+	process["swoop_workflow_uuid"] = wfUuid.String()
+	if cwo.SfnArn != "" {
+		process["sfn_arn"] = cwo.SfnArn
+	}
+
+	p["process"] = process
+
+	return p, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+func Test_CirrusDecoratePayload(t *testing.T) {
+	wfUuid := uuid.Must(uuid.NewV4())
+	opts := &CirrusWorkflowOpts{SfnArn: "arn:aws:states:us-west-2:0:stateMachine:mirror"}
+
+	payload, err := opts.DecoratePayload(
+		map[string]any{
+			"features": []any{},
+			"process":  map[string]any{"upload_options": map[string]any{}},
+		},
+		"mirror",
+		wfUuid,
+	)
+	if err != nil {
+		t.Fatalf("failed to decorate payload: %s", err)
+	}
+
+	process := payload["process"].(map[string]any)
+	if process["workflow"] != "mirror" {
+		t.Fatalf("unexpected workflow: '%v'", process["workflow"])
+	}
+	if process["swoop_workflow_uuid"] != wfUuid.String() {
+		t.Fatalf("unexpected uuid: '%v'", process["swoop_workflow_uuid"])
+	}
+	if process["sfn_arn"] != opts.SfnArn {
+		t.Fatalf("unexpected sfn arn: '%v'", process["sfn_arn"])
+	}
+	if _, ok := process["upload_options"]; !ok {
+		t.Fatal("existing process options should be preserved")
+	}
+
+	_, err = opts.DecoratePayload([]any{}, "mirror", wfUuid)
+	if err == nil {
+		t.Fatal("non-object payload should error")
+	}
+}
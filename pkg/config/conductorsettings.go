@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+type ConductorSettings struct {
+	// PollInterval is how often handlers poll for actions absent notifications
+	PollInterval time.Duration `default:"600s" yaml:"pollInterval"`
+	// BatchSize is the max number of actions fetched per handler query
+	BatchSize int `default:"100" yaml:"batchSize"`
+	// Workers is the size of a worker pool shared by all handlers. If
+	// unset, each handler has its own pool sized to its max concurrency.
+	Workers int `yaml:"workers,omitempty"`
+	// Webhook configures the server receiving asynchttp action results
+	Webhook *WebhookSettings `yaml:"webhook,omitempty"`
+}
+
+type WebhookSettings struct {
+	// Listen is the address the webhook server binds to
+	Listen string `default:":8080" yaml:"listen"`
+	// ExternalUrl is the base url at which remote services can reach the
+	// webhook server, used to build the callback url for each action
+	ExternalUrl string `yaml:"externalUrl"`
+}
+
+func (ws *WebhookSettings) Validate() error {
+	if ws.ExternalUrl == "" {
+		return errors.New("webhook must define 'externalUrl'")
+	}
+
+	u, err := url.Parse(ws.ExternalUrl)
+	if err != nil {
+		return fmt.Errorf("webhook externalUrl is not valid: %s", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook externalUrl must be http(s), got '%s'", ws.ExternalUrl)
+	}
+
+	return nil
+}
+
+func (ws *WebhookSettings) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(ws)
+
+	type p WebhookSettings
+
+	err := unmarshal((*p)(ws))
+	if err != nil {
+		return err
+	}
+
+	return ws.Validate()
+}
+
+func NewConductorSettings() *ConductorSettings {
+	s := &ConductorSettings{}
+	defaults.Set(s)
+	return s
+}
+
+func (s *ConductorSettings) Validate() error {
+	if s.PollInterval < time.Second {
+		return fmt.Errorf("pollInterval must be at least 1s, got '%s'", s.PollInterval)
+	}
+
+	if s.BatchSize < 1 {
+		return fmt.Errorf("batchSize must be at least 1, got '%d'", s.BatchSize)
+	}
+
+	if s.Workers < 0 {
+		return fmt.Errorf("workers must not be negative, got '%d'", s.Workers)
+	}
+
+	return nil
+}
+
+func (s *ConductorSettings) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(s)
+
+	type p ConductorSettings
+
+	err := unmarshal((*p)(s))
+	if err != nil {
+		return err
+	}
+
+	return s.Validate()
+}
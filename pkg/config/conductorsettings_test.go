@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_ConductorSettingsDefaults(t *testing.T) {
+	s := &ConductorSettings{}
+
+	err := yaml.Unmarshal([]byte(`batchSize: 10`), s)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	expected := ConductorSettings{PollInterval: 600 * time.Second, BatchSize: 10}
+	if *s != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *s)
+	}
+}
+
+func Test_ConductorSettingsBad(t *testing.T) {
+	for _, yml := range []string{
+		"pollInterval: 10ms",
+		"batchSize: 0",
+		"workers: -1",
+	} {
+		t.Run(
+			yml,
+			func(t *testing.T) {
+				err := yaml.Unmarshal([]byte(yml), &ConductorSettings{})
+				if err == nil {
+					t.Fatal("expected validation error")
+				}
+			},
+		)
+	}
+}
@@ -21,33 +21,19 @@ func (hs *Handlers) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
-// TODO: how does this even work?
-//
-//	I think we need another type to actually fetch/retrive the value?
-//	Or maybe it just checks the value at read time,
-//	and if enough time has passed since last read it tries to read again?
-//	If error on read after startup use last value and log error
-//	-> next read should be scheduled, and we can use that to backoff reads on error
-type HandlerSecret struct {
-	Name string `yaml:"name"`
-	Type string `yaml:"type"`
-	Path string `yaml:"path"`
-	TTL  int    `yaml:"ttl"`
-}
-
 type Handler struct {
-	Name string      `yaml:"-"`
-	Type HandlerType `yaml:"type"`
-	// TODO: need a backoff type
-	Backoff    map[string]int     `yaml:"backoff"`
+	Name       string             `yaml:"-"`
+	Type       HandlerType        `yaml:"type"`
+	Backoff    *HandlerBackoff    `yaml:"backoff"`
+	Limits     *HandlerLimits     `yaml:"limits"`
+	Debounce   *HandlerDebounce   `yaml:"debounce"`
 	Parameters *HandlerParameters `yaml:"parameters"`
 	Secrets    []*HandlerSecret   `yaml:"secrets"`
 	Workflows  []*Workflow        `yaml:"-"`
 	HttpClient *http.Client       `yaml:"request,omitempty"`
 	ArgoConf   *ArgoConf          `yaml:"argoConf,omitempty"`
-
-	// TODO: cirrus options
-	// not sure how this is going to work yet, just make a placeholder
+	NoopConf   *NoopConf          `yaml:"noopConf,omitempty"`
+	CirrusConf *CirrusConf        `yaml:"cirrusConf,omitempty"`
 }
 
 func (h *Handler) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -58,6 +44,10 @@ func (h *Handler) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	if h.Backoff == nil {
+		h.Backoff = NewHandlerBackoff()
+	}
+
 	// TODO: Validate to ensure we have what we need/is allowed
 	// Probably should start a convention here to use separate method for validation?
 	// Consider https://github.com/dealancer/validate (but looks unmaintained...)
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/creasty/defaults"
+)
+
+type HandlerBackoff struct {
+	// Retries is the max number of times a failed action will be retried
+	Retries int `default:"10" yaml:"retries"`
+	// Seconds is the delay before the first retry
+	Seconds int `default:"60" yaml:"seconds"`
+	// Factor is the multiplier applied to the delay for each successive retry
+	Factor float64 `default:"2" yaml:"factor"`
+	// Max is the upper bound on the delay between retries, in seconds
+	Max int `default:"3600" yaml:"max"`
+}
+
+func NewHandlerBackoff() *HandlerBackoff {
+	b := &HandlerBackoff{}
+	defaults.Set(b)
+	return b
+}
+
+// RetrySeconds returns the delay in seconds before the action can be
+// retried, given the number of times it has already been retried. If the
+// retries are exhausted, the returned bool will be false.
+func (b *HandlerBackoff) RetrySeconds(retries int) (int, bool) {
+	if retries >= b.Retries {
+		return 0, false
+	}
+
+	seconds := float64(b.Seconds) * math.Pow(b.Factor, float64(retries))
+	if seconds > float64(b.Max) {
+		return b.Max, true
+	}
+
+	return int(seconds), true
+}
+
+func (b *HandlerBackoff) Validate() error {
+	if b.Retries < 0 {
+		return fmt.Errorf("retries must not be negative, got '%d'", b.Retries)
+	}
+
+	if b.Seconds < 1 {
+		return fmt.Errorf("seconds must be at least 1, got '%d'", b.Seconds)
+	}
+
+	if b.Factor < 1 {
+		return fmt.Errorf("factor must be at least 1, got '%v'", b.Factor)
+	}
+
+	if b.Max < b.Seconds {
+		return fmt.Errorf("max '%d' must not be less than seconds '%d'", b.Max, b.Seconds)
+	}
+
+	return nil
+}
+
+func (b *HandlerBackoff) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(b)
+
+	type p HandlerBackoff
+
+	err := unmarshal((*p)(b))
+	if err != nil {
+		return err
+	}
+
+	return b.Validate()
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_HandlerBackoff(t *testing.T) {
+	yml := `
+retries: 4
+seconds: 5
+factor: 2
+max: 25
+`
+	b := &HandlerBackoff{}
+
+	err := yaml.Unmarshal([]byte(yml), b)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	for retries, expected := range []int{5, 10, 20, 25} {
+		seconds, ok := b.RetrySeconds(retries)
+		if !ok {
+			t.Fatalf("retry %d should not be exhausted", retries)
+		}
+		if seconds != expected {
+			t.Fatalf("retry %d expected %d seconds, got %d", retries, expected, seconds)
+		}
+	}
+
+	_, ok := b.RetrySeconds(4)
+	if ok {
+		t.Fatal("retries should be exhausted after 4 retries")
+	}
+}
+
+func Test_HandlerBackoffDefaults(t *testing.T) {
+	b := &HandlerBackoff{}
+
+	err := yaml.Unmarshal([]byte(`retries: 2`), b)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	expected := HandlerBackoff{Retries: 2, Seconds: 60, Factor: 2, Max: 3600}
+	if *b != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *b)
+	}
+}
+
+func Test_HandlerBackoffBad(t *testing.T) {
+	for _, yml := range []string{
+		"retries: -1",
+		"seconds: 0",
+		"factor: 0.5",
+		"{seconds: 10, max: 5}",
+	} {
+		t.Run(
+			yml,
+			func(t *testing.T) {
+				err := yaml.Unmarshal([]byte(yml), &HandlerBackoff{})
+				if err == nil {
+					t.Fatal("should have errored parsing yaml, but didn't")
+				}
+			},
+		)
+	}
+}
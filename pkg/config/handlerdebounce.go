@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+type HandlerDebounce struct {
+	// Window is the max time to hold notifications before querying; zero
+	// disables debouncing
+	Window time.Duration `yaml:"window"`
+	// Count is the number of held notifications that triggers a query
+	// before the window has elapsed; zero means only the window applies
+	Count int `yaml:"count"`
+}
+
+func (hd *HandlerDebounce) Validate() error {
+	if hd.Window < 0 {
+		return fmt.Errorf("window must not be negative, got '%s'", hd.Window)
+	}
+
+	if hd.Count < 0 {
+		return fmt.Errorf("count must not be negative, got '%d'", hd.Count)
+	}
+
+	if hd.Window == 0 && hd.Count > 1 {
+		return fmt.Errorf("count requires a window, else notifications could be held indefinitely")
+	}
+
+	return nil
+}
+
+func (hd *HandlerDebounce) GetWindow() time.Duration {
+	if hd == nil {
+		return 0
+	}
+	return hd.Window
+}
+
+func (hd *HandlerDebounce) GetCount() int {
+	if hd == nil {
+		return 0
+	}
+	return hd.Count
+}
+
+func (hd *HandlerDebounce) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p HandlerDebounce
+
+	err := unmarshal((*p)(hd))
+	if err != nil {
+		return err
+	}
+
+	return hd.Validate()
+}
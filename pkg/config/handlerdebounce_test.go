@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_HandlerDebounce(t *testing.T) {
+	d := &HandlerDebounce{}
+
+	err := yaml.Unmarshal([]byte(`{window: 500ms, count: 10}`), d)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	if d.GetWindow() != 500*time.Millisecond {
+		t.Fatalf("expected window 500ms, got %s", d.GetWindow())
+	}
+	if d.GetCount() != 10 {
+		t.Fatalf("expected count 10, got %d", d.GetCount())
+	}
+}
+
+func Test_HandlerDebounceDefaults(t *testing.T) {
+	var d *HandlerDebounce
+
+	// an unset debounce disables debouncing
+	if d.GetWindow() != 0 || d.GetCount() != 0 {
+		t.Fatalf("expected no debouncing, got window %s and count %d", d.GetWindow(), d.GetCount())
+	}
+
+	for _, yml := range []string{
+		"{}",
+		"window: 0s",
+		"{window: 0s, count: 1}",
+	} {
+		t.Run(
+			yml,
+			func(t *testing.T) {
+				err := yaml.Unmarshal([]byte(yml), &HandlerDebounce{})
+				if err != nil {
+					t.Fatalf("error parsing yaml: %s", err)
+				}
+			},
+		)
+	}
+}
+
+func Test_HandlerDebounceBad(t *testing.T) {
+	for _, yml := range []string{
+		"window: -1s",
+		"count: -1",
+		"{window: 1s, count: -1}",
+		"count: 2",
+		"{window: 0s, count: 2}",
+	} {
+		t.Run(
+			yml,
+			func(t *testing.T) {
+				err := yaml.Unmarshal([]byte(yml), &HandlerDebounce{})
+				if err == nil {
+					t.Fatal("should have errored parsing yaml, but didn't")
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+)
+
+// default in-flight action limit when neither a rate
+// nor a max concurrency is specified for a handler
+const defaultMaxConcurrency = 100
+
+type HandlerLimits struct {
+	// Rate is the max number of actions per second; zero is unlimited
+	Rate float64 `yaml:"rate"`
+	// MaxConcurrency is the max number of actions in process at any one time
+	MaxConcurrency int `yaml:"maxConcurrency"`
+}
+
+func (hl *HandlerLimits) Validate() error {
+	if hl.Rate < 0 {
+		return fmt.Errorf("rate must not be negative, got '%v'", hl.Rate)
+	}
+
+	if hl.MaxConcurrency < 0 {
+		return fmt.Errorf("maxConcurrency must not be negative, got '%d'", hl.MaxConcurrency)
+	}
+
+	return nil
+}
+
+// GetMaxConcurrency returns the configured max concurrency, else defaults to
+// Max(int(Rate), 1) when a rate is set, else to defaultMaxConcurrency.
+func (hl *HandlerLimits) GetMaxConcurrency() int {
+	if hl == nil {
+		return defaultMaxConcurrency
+	}
+
+	if hl.MaxConcurrency > 0 {
+		return hl.MaxConcurrency
+	}
+
+	if hl.Rate > 0 {
+		return max(int(hl.Rate), 1)
+	}
+
+	return defaultMaxConcurrency
+}
+
+func (hl *HandlerLimits) GetRate() float64 {
+	if hl == nil {
+		return 0
+	}
+	return hl.Rate
+}
+
+func (hl *HandlerLimits) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p HandlerLimits
+
+	err := unmarshal((*p)(hl))
+	if err != nil {
+		return err
+	}
+
+	return hl.Validate()
+}
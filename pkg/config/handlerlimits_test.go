@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_HandlerLimits(t *testing.T) {
+	for _, tc := range []struct {
+		yml            string
+		rate           float64
+		maxConcurrency int
+	}{
+		{"{rate: 5, maxConcurrency: 2}", 5, 2},
+		{"rate: 20", 20, 20},
+		{"rate: 0.5", 0.5, 1},
+		{"maxConcurrency: 3", 0, 3},
+		{"{}", 0, defaultMaxConcurrency},
+		{"rate: 0", 0, defaultMaxConcurrency},
+	} {
+		t.Run(
+			tc.yml,
+			func(t *testing.T) {
+				l := &HandlerLimits{}
+
+				err := yaml.Unmarshal([]byte(tc.yml), l)
+				if err != nil {
+					t.Fatalf("error parsing yaml: %s", err)
+				}
+
+				if l.GetRate() != tc.rate {
+					t.Fatalf("expected rate %v, got %v", tc.rate, l.GetRate())
+				}
+				if l.GetMaxConcurrency() != tc.maxConcurrency {
+					t.Fatalf(
+						"expected max concurrency %d, got %d",
+						tc.maxConcurrency,
+						l.GetMaxConcurrency(),
+					)
+				}
+			},
+		)
+	}
+}
+
+func Test_HandlerLimitsDefaults(t *testing.T) {
+	var l *HandlerLimits
+
+	if l.GetRate() != 0 {
+		t.Fatalf("expected unlimited rate, got %v", l.GetRate())
+	}
+	if l.GetMaxConcurrency() != defaultMaxConcurrency {
+		t.Fatalf(
+			"expected max concurrency %d, got %d",
+			defaultMaxConcurrency,
+			l.GetMaxConcurrency(),
+		)
+	}
+}
+
+func Test_HandlerLimitsBad(t *testing.T) {
+	for _, yml := range []string{
+		"rate: -1",
+		"rate: -0.5",
+		"maxConcurrency: -1",
+		"{rate: 5, maxConcurrency: -2}",
+	} {
+		t.Run(
+			yml,
+			func(t *testing.T) {
+				err := yaml.Unmarshal([]byte(yml), &HandlerLimits{})
+				if err == nil {
+					t.Fatal("should have errored parsing yaml, but didn't")
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+type HandlerSecret struct {
+	Name string     `yaml:"name"`
+	Type SecretType `yaml:"type"`
+	// Path is the file to read for file secrets
+	Path string `yaml:"path,omitempty"`
+	// Var is the environment variable to read for env secrets
+	Var string `yaml:"var,omitempty"`
+	// K8s identifies the kubernetes secret to read for k8s secrets
+	K8s *K8sSecretConf `yaml:"k8s,omitempty"`
+	// Vault identifies the KV v2 secret to read for vault secrets
+	Vault *VaultSecretConf `yaml:"vault,omitempty"`
+	// TTL is how long in seconds a resolved value is used before it is
+	// resolved again; zero means the value is resolved only once
+	TTL int `yaml:"ttl"`
+}
+
+func (hs *HandlerSecret) GetTTL() time.Duration {
+	return time.Duration(hs.TTL) * time.Second
+}
+
+func (hs *HandlerSecret) Validate() error {
+	if hs.Name == "" {
+		return fmt.Errorf("secrets must define 'name'")
+	}
+
+	if hs.TTL < 0 {
+		return fmt.Errorf("secret '%s': ttl must not be negative, got '%d'", hs.Name, hs.TTL)
+	}
+
+	switch hs.Type {
+	case FileSecret:
+		if hs.Path == "" {
+			return fmt.Errorf("secret '%s': file secrets must define 'path'", hs.Name)
+		}
+	case EnvSecret:
+		if hs.Var == "" {
+			return fmt.Errorf("secret '%s': env secrets must define 'var'", hs.Name)
+		}
+	case K8sSecret:
+		if hs.K8s == nil {
+			return fmt.Errorf("secret '%s': k8s secrets must define 'k8s'", hs.Name)
+		}
+		err := hs.K8s.Validate()
+		if err != nil {
+			return fmt.Errorf("secret '%s': %s", hs.Name, err)
+		}
+	case VaultSecret:
+		if hs.Vault == nil {
+			return fmt.Errorf("secret '%s': vault secrets must define 'vault'", hs.Name)
+		}
+		err := hs.Vault.Validate()
+		if err != nil {
+			return fmt.Errorf("secret '%s': %s", hs.Name, err)
+		}
+	default:
+		return fmt.Errorf("secret '%s': unsupported secret type '%s'", hs.Name, hs.Type)
+	}
+
+	return nil
+}
+
+func (hs *HandlerSecret) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p HandlerSecret
+
+	err := unmarshal((*p)(hs))
+	if err != nil {
+		return err
+	}
+
+	return hs.Validate()
+}
+
+type K8sSecretConf struct {
+	// Namespace defaults to that of the k8s client config
+	Namespace  string      `yaml:"namespace,omitempty"`
+	Name       string      `yaml:"name"`
+	Key        string      `yaml:"key"`
+	K8sOptions *K8sOptions `yaml:"k8sOptions,omitempty"`
+}
+
+func (ksc *K8sSecretConf) Validate() error {
+	if ksc.Name == "" || ksc.Key == "" {
+		return fmt.Errorf("k8s secrets must define 'name' and 'key'")
+	}
+	return nil
+}
+
+type VaultAuthMethod string
+
+const (
+	VaultTokenAuth VaultAuthMethod = "token"
+	VaultK8sAuth   VaultAuthMethod = "kubernetes"
+)
+
+type VaultSecretConf struct {
+	// Address defaults to the VAULT_ADDR environment variable
+	Address string `yaml:"address,omitempty"`
+	// Mount is the path of the KV v2 secrets engine
+	Mount string          `default:"secret" yaml:"mount"`
+	Path  string          `yaml:"path"`
+	Key   string          `yaml:"key"`
+	Auth  VaultAuthConfig `yaml:"auth"`
+}
+
+type VaultAuthConfig struct {
+	Method VaultAuthMethod `default:"token" yaml:"method"`
+	// TokenVar is the environment variable holding the token for token auth
+	TokenVar string `default:"VAULT_TOKEN" yaml:"tokenVar"`
+	// Role, Mount, and JwtPath are used for kubernetes auth
+	Role    string `yaml:"role,omitempty"`
+	Mount   string `default:"kubernetes" yaml:"mount"`
+	JwtPath string `default:"/var/run/secrets/kubernetes.io/serviceaccount/token" yaml:"jwtPath"`
+}
+
+func (vsc *VaultSecretConf) Validate() error {
+	if vsc.Path == "" || vsc.Key == "" {
+		return fmt.Errorf("vault secrets must define 'path' and 'key'")
+	}
+
+	switch vsc.Auth.Method {
+	case VaultTokenAuth:
+	case VaultK8sAuth:
+		if vsc.Auth.Role == "" {
+			return fmt.Errorf("vault kubernetes auth must define 'role'")
+		}
+	default:
+		return fmt.Errorf("unsupported vault auth method '%s'", vsc.Auth.Method)
+	}
+
+	return nil
+}
+
+func (vsc *VaultSecretConf) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(vsc)
+
+	type p VaultSecretConf
+
+	err := unmarshal((*p)(vsc))
+	if err != nil {
+		return err
+	}
+
+	return vsc.Validate()
+}
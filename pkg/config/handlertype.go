@@ -10,6 +10,7 @@ type HandlerType string
 const (
 	Noop          HandlerType = "noop"
 	SyncHttp      HandlerType = "synchttp"
+	AsyncHttp     HandlerType = "asynchttp"
 	ArgoWorkflows HandlerType = "argoworkflows"
 	Cirrus        HandlerType = "cirrus"
 )
@@ -17,6 +18,7 @@ const (
 var HandlerTypes = map[HandlerType]struct{}{
 	Noop:          {},
 	SyncHttp:      {},
+	AsyncHttp:     {},
 	ArgoWorkflows: {},
 	Cirrus:        {},
 }
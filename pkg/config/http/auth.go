@@ -0,0 +1,270 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creasty/defaults"
+
+	"github.com/element84/swoop-go/pkg/config/template"
+	"github.com/element84/swoop-go/pkg/errors"
+)
+
+type AuthType string
+
+const (
+	ClientCredentialsAuth AuthType = "clientCredentials"
+)
+
+var authTypes = map[AuthType]struct{}{
+	ClientCredentialsAuth: {},
+}
+
+func (at AuthType) String() string {
+	return string(at)
+}
+
+func parseAuthType(s string) (AuthType, error) {
+	at := AuthType(s)
+
+	_, ok := authTypes[at]
+	if !ok {
+		return "", fmt.Errorf("unknown auth type '%s'", s)
+	}
+
+	return at, nil
+}
+
+func (at *AuthType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var a string
+
+	err := unmarshal(&a)
+	if err != nil {
+		return err
+	}
+
+	*at, err = parseAuthType(a)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AuthConfig authenticates requests with a bearer token from an OAuth2
+// token endpoint using the client credentials grant. The client id and
+// secret are templates, so they can be taken from the handler secrets.
+type AuthConfig struct {
+	Type         AuthType           `yaml:"type"`
+	TokenUrl     string             `yaml:"tokenUrl"`
+	ClientId     *template.Template `yaml:"clientId"`
+	ClientSecret *template.Template `yaml:"clientSecret"`
+	Scopes       []string           `yaml:"scopes"`
+	// ExpiryMargin is how long before its expiry a token is refreshed
+	ExpiryMargin time.Duration `default:"30s" yaml:"expiryMargin"`
+
+	tokens *tokenCache
+}
+
+// credentials are the templated client credentials for a request
+type credentials struct {
+	clientId     string
+	clientSecret string
+}
+
+func (ac *AuthConfig) credentials(data any) (*credentials, error) {
+	if ac == nil {
+		return nil, nil
+	}
+
+	clientId, err := ac.ClientId.ExecuteToString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret, err := ac.ClientSecret.ExecuteToString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &credentials{clientId, clientSecret}, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// fetchToken requests a new token from the token endpoint. Failures are
+// retryable, as they may be resolved by rotating the client credentials.
+func (ac *AuthConfig) fetchToken(
+	ctx context.Context,
+	client *Client,
+	creds *credentials,
+) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(ac.Scopes) > 0 {
+		form.Set("scope", strings.Join(ac.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		ac.TokenUrl,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", time.Time{}, errors.NewRequestError(err, false)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(creds.clientId), url.QueryEscape(creds.clientSecret))
+
+	httpResp, err := client.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, client.TransportErrors.toRequestError(err)
+	}
+
+	resp, err := readResponse(httpResp, client.MaxResponseBytes)
+	if err != nil {
+		return "", time.Time{}, client.TransportErrors.toRequestError(err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", time.Time{}, errors.NewRequestError(
+			fmt.Errorf("token request failed with status %d", resp.StatusCode),
+			true,
+		)
+	}
+
+	token := &tokenResponse{}
+	err = json.Unmarshal([]byte(resp.Body), token)
+	if err != nil || token.AccessToken == "" {
+		return "", time.Time{}, errors.NewRequestError(
+			fmt.Errorf("token response did not include an access token"),
+			true,
+		)
+	}
+
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", time.Time{}, errors.NewRequestError(
+			fmt.Errorf("unsupported token type '%s'", token.TokenType),
+			false,
+		)
+	}
+
+	// without an expiry the token is used until it is rejected
+	var expires time.Time
+	if token.ExpiresIn > 0 {
+		expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - ac.ExpiryMargin)
+	}
+
+	return token.AccessToken, expires, nil
+}
+
+// tokenCache holds the current token, which is replaced if it expires,
+// is rejected, or the client credentials change
+type tokenCache struct {
+	mu      sync.Mutex
+	creds   credentials
+	token   string
+	expires time.Time
+}
+
+func (tc *tokenCache) valid(creds *credentials) bool {
+	return tc.token != "" &&
+		tc.creds == *creds &&
+		(tc.expires.IsZero() || time.Now().Before(tc.expires))
+}
+
+// get returns a valid token, fetching a new one if required
+func (tc *tokenCache) get(
+	ctx context.Context,
+	ac *AuthConfig,
+	client *Client,
+	creds *credentials,
+) (string, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.valid(creds) {
+		return tc.token, nil
+	}
+
+	token, expires, err := ac.fetchToken(ctx, client, creds)
+	if err != nil {
+		return "", err
+	}
+
+	tc.creds = *creds
+	tc.token = token
+	tc.expires = expires
+	return token, nil
+}
+
+// invalidate drops the token if it is still the cached one, so
+// concurrent requests rejected with the same token fetch it only once
+func (tc *tokenCache) invalidate(token string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.token == token {
+		tc.token = ""
+	}
+}
+
+// authorize adds a bearer token to the outgoing request, returning the token
+func (ac *AuthConfig) authorize(
+	ctx context.Context,
+	client *Client,
+	creds *credentials,
+	req *http.Request,
+) (string, error) {
+	token, err := ac.tokens.get(ctx, ac, client, creds)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return token, nil
+}
+
+func (ac *AuthConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(ac)
+
+	type p AuthConfig
+
+	err := unmarshal((*p)(ac))
+	if err != nil {
+		return err
+	}
+
+	if ac.Type == "" {
+		return fmt.Errorf("auth type is required")
+	}
+
+	u, err := url.Parse(ac.TokenUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("auth tokenUrl must be an http(s) url, got '%s'", ac.TokenUrl)
+	}
+
+	if ac.ClientId == nil || ac.ClientSecret == nil {
+		return fmt.Errorf("auth requires a clientId and clientSecret")
+	}
+
+	if ac.ExpiryMargin < 0 {
+		return fmt.Errorf("auth expiryMargin must not be negative, got '%s'", ac.ExpiryMargin)
+	}
+
+	ac.tokens = &tokenCache{}
+
+	return nil
+}
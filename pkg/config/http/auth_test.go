@@ -0,0 +1,176 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+type tokenServer struct {
+	Server    *httptest.Server
+	ExpiresIn int
+	issued    atomic.Int32
+}
+
+func (ts *tokenServer) handler(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != "swoop" || secret != "hunter2" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "a b" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": fmt.Sprintf("token-%d", ts.issued.Add(1)),
+		"token_type":   "Bearer",
+		"expires_in":   ts.ExpiresIn,
+	})
+}
+
+func mkTokenServer(t testing.TB, expiresIn int) *tokenServer {
+	ts := &tokenServer{ExpiresIn: expiresIn}
+	ts.Server = httptest.NewServer(http.HandlerFunc(ts.handler))
+	t.Cleanup(ts.Server.Close)
+	return ts
+}
+
+func mkAuthClient(t testing.TB, tokenUrl, url string) *Client {
+	hr := &Client{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+url: %s
+method: POST
+body: '{}'
+auth:
+  type: clientCredentials
+  tokenUrl: %s
+  clientId: "{{ .secrets.clientId }}"
+  clientSecret: "{{ .secrets.clientSecret }}"
+  scopes: [a, b]
+`, url, tokenUrl)), hr)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+	return hr
+}
+
+func Test_AuthClientCredentials(t *testing.T) {
+	ctx := context.Background()
+	tokens := mkTokenServer(t, 3600)
+
+	var revoked atomic.Value
+	revoked.Store("")
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" || auth == "Bearer "+revoked.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, auth)
+	}))
+	t.Cleanup(api.Close)
+
+	hr := mkAuthClient(t, tokens.Server.URL, api.URL)
+	data := map[string]any{
+		"secrets": map[string]string{"clientId": "swoop", "clientSecret": "hunter2"},
+	}
+
+	makeRequest := func(expected string) {
+		req, err := hr.NewRequest(data)
+		if err != nil {
+			t.Fatalf("error templating request: %s", err)
+		}
+
+		resp, err := hr.MakeRequest(ctx, req)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+
+		if resp.Body != expected {
+			t.Fatalf("expected '%s', got '%s'", expected, resp.Body)
+		}
+
+		if req.Header.Get("Authorization") != "" {
+			t.Fatal("token should not be added to the original request")
+		}
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		makeRequest("Bearer token-1")
+		makeRequest("Bearer token-1")
+		if issued := tokens.issued.Load(); issued != 1 {
+			t.Fatalf("expected token to be cached, but %d were issued", issued)
+		}
+	})
+
+	t.Run("refreshed on 401", func(t *testing.T) {
+		revoked.Store("token-1")
+		makeRequest("Bearer token-2")
+	})
+
+	t.Run("bad credentials", func(t *testing.T) {
+		data["secrets"] = map[string]string{"clientId": "swoop", "clientSecret": "wrong"}
+		req, err := hr.NewRequest(data)
+		if err != nil {
+			t.Fatalf("error templating request: %s", err)
+		}
+
+		_, err = hr.MakeRequest(ctx, req)
+		result, ok := RequestResultFromError(err)
+		if !ok || result != Error {
+			t.Fatalf("token failure should be a retryable error, got: %v", err)
+		}
+	})
+}
+
+func Test_AuthExpiry(t *testing.T) {
+	// a token that expires within the margin is never reused
+	tokens := mkTokenServer(t, 10)
+	api := mkTestServer(t, 200, "")
+
+	hr := mkAuthClient(t, tokens.Server.URL, api.Server.URL)
+	data := map[string]any{
+		"secrets": map[string]string{"clientId": "swoop", "clientSecret": "hunter2"},
+	}
+
+	for i := 0; i < 2; i++ {
+		req, err := hr.NewRequest(data)
+		if err != nil {
+			t.Fatalf("error templating request: %s", err)
+		}
+
+		_, err = hr.MakeRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+	}
+
+	if issued := tokens.issued.Load(); issued != 2 {
+		t.Fatalf("expected expired token to be refreshed, but %d were issued", issued)
+	}
+}
+
+func Test_AuthConfig(t *testing.T) {
+	for _, yml := range []string{
+		"{type: password, tokenUrl: 'https://a', clientId: a, clientSecret: b}",
+		"{tokenUrl: 'https://a', clientId: a, clientSecret: b}",
+		"{type: clientCredentials, tokenUrl: 'ftp://a', clientId: a, clientSecret: b}",
+		"{type: clientCredentials, tokenUrl: 'https://a', clientId: a}",
+		"{type: clientCredentials, tokenUrl: 'https://a', clientId: a, clientSecret: b, expiryMargin: -1s}",
+	} {
+		err := yaml.Unmarshal([]byte(yml), &AuthConfig{})
+		if err == nil {
+			t.Fatalf("should have failed to parse '%s'", yml)
+		}
+	}
+}
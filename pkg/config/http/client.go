@@ -3,11 +3,15 @@ package http
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/creasty/defaults"
 
 	"github.com/element84/swoop-go/pkg/config/template"
+	swooperrs "github.com/element84/swoop-go/pkg/errors"
 )
 
 type Client struct {
@@ -17,11 +21,40 @@ type Client struct {
 	Headers         map[string]*template.Template `yaml:"headers"`
 	ResponseChecker *responseChecker              `yaml:"responses"`
 	Follow          bool                          `default:"true" yaml:"followRedirects"`
-	Transport       *http.Transport               `yaml:"transport"`
-	client          *http.Client
+	Transport       *TransportConfig              `yaml:"transport"`
+	Auth            *AuthConfig                   `yaml:"auth"`
+	Signing         *SigningConfig                `yaml:"signing"`
+	Output          Output                        `yaml:"output"`
+	Log             *LogConfig                    `yaml:"log"`
+	TransportErrors TransportErrors               `yaml:"transportErrors"`
+	Timeouts        Timeouts                      `yaml:"timeouts"`
+	// MaxResponseBytes limits how much of a response body is read
+	MaxResponseBytes int64 `default:"1048576" yaml:"maxResponseBytes"`
+	client           *http.Client
 }
 
-func (s *Client) NewRequest(data any) (*http.Request, error) {
+type Timeouts struct {
+	// Connect limits how long establishing a connection may take
+	Connect time.Duration `default:"10s" yaml:"connect"`
+	// Total limits the whole request, including reading the response
+	Total time.Duration `default:"60s" yaml:"total"`
+}
+
+func (t *Timeouts) Validate() error {
+	if t.Connect <= 0 || t.Total <= 0 {
+		return fmt.Errorf("timeouts must be positive, got connect '%s' and total '%s'", t.Connect, t.Total)
+	}
+	return nil
+}
+
+// Request is an http request along with what is needed to authenticate it
+type Request struct {
+	*http.Request
+	credentials *credentials
+	signingKey  string
+}
+
+func (s *Client) NewRequest(data any) (*Request, error) {
 	url, err := s.Url.Execute(data)
 	if err != nil {
 		return nil, err
@@ -47,18 +80,86 @@ func (s *Client) NewRequest(data any) (*http.Request, error) {
 		req.Header.Set(headerName, headerValue)
 	}
 
-	return req, nil
+	creds, err := s.Auth.credentials(data)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := s.Signing.key(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Request{req, creds, signingKey}, nil
+}
+
+func (s *Client) do(req *http.Request) (*Response, error) {
+	httpResp, err := s.client.Do(req)
+	if err != nil {
+		return nil, s.TransportErrors.toRequestError(err)
+	}
+
+	resp, err := readResponse(httpResp, s.MaxResponseBytes)
+	if err != nil {
+		return nil, s.TransportErrors.toRequestError(err)
+	}
+
+	return resp, nil
+}
+
+// send makes a copy of the request with any auth and signing added,
+// returning the response and the auth token used, if any
+func (s *Client) send(ctx context.Context, req *Request) (*Response, string, error) {
+	outReq := req.Clone(ctx)
+	if req.GetBody != nil {
+		var err error
+		outReq.Body, err = req.GetBody()
+		if err != nil {
+			return nil, "", swooperrs.NewRequestError(err, false)
+		}
+	}
+
+	var token string
+	if s.Auth != nil && req.credentials != nil {
+		var err error
+		token, err = s.Auth.authorize(ctx, s, req.credentials, outReq)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err := s.Signing.sign(outReq, req.signingKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := s.do(outReq)
+	return resp, token, err
 }
 
-func (s *Client) MakeRequest(ctx context.Context, req *http.Request) (*Response, error) {
-	resp, err := wrapRequest(s.client.Do(req.WithContext(ctx)))
+func (s *Client) MakeRequest(ctx context.Context, req *Request) (*Response, error) {
+	err := checkUrl(req.URL)
 	if err != nil {
-		// TODO: how to differentiate between terminal and transient errors in client
-		return resp, err
+		return nil, s.TransportErrors.toRequestError(err)
+	}
+
+	resp, token, err := s.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// the token may have been revoked before it expired,
+	// so we get a new one and retry once
+	if resp.StatusCode == http.StatusUnauthorized && token != "" {
+		s.Auth.tokens.invalidate(token)
+
+		resp, _, err = s.send(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	err = s.ResponseChecker.check(resp)
-	return resp, err
+	return resp, s.ResponseChecker.check(resp)
 }
 
 func (s *Client) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -71,10 +172,28 @@ func (s *Client) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
-	s.client = &http.Client{}
+	err = s.Timeouts.Validate()
+	if err != nil {
+		return err
+	}
+
+	if s.MaxResponseBytes < 1 {
+		return fmt.Errorf("maxResponseBytes must be at least 1, got '%d'", s.MaxResponseBytes)
+	}
+
+	transport := s.Transport.Transport()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   s.Timeouts.Connect,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
 
-	if s.Transport != nil {
-		s.client.Transport = s.Transport
+	s.client = &http.Client{
+		Transport: transport,
+		Timeout:   s.Timeouts.Total,
+	}
+
+	if s.Auth != nil && s.Signing != nil && s.Signing.Type == SigV4Signing {
+		return fmt.Errorf("auth cannot be used with sigv4 signing")
 	}
 
 	if !s.Follow {
@@ -86,5 +205,5 @@ func (s *Client) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	// TODO: ensure we have required fields
 	// separate validate method?
 
-	return nil
+	return s.TransportErrors.Validate()
 }
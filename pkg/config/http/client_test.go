@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -143,3 +144,54 @@ func Test_Client(t *testing.T) {
 		)
 	}
 }
+
+func Test_ClientTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	t.Cleanup(ts.Close)
+
+	hr := &Client{}
+	err := yaml.Unmarshal([]byte(`{method: GET, timeouts: {total: 50ms}}`), hr)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	_, err = hr.MakeRequest(context.Background(), &Request{Request: req})
+
+	result, ok := RequestResultFromError(err)
+	if !ok || result != Error {
+		t.Fatalf("timeout should be a retryable error, got: %v", err)
+	}
+}
+
+func Test_ClientMaxResponseBytes(t *testing.T) {
+	ts := mkTestServer(t, 200, "0123456789")
+
+	hr := &Client{}
+	err := yaml.Unmarshal([]byte(`{method: GET, maxResponseBytes: 4}`), hr)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.Server.URL, nil)
+	resp, err := hr.MakeRequest(context.Background(), &Request{Request: req})
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+
+	if resp.Body != "0123" || !resp.Truncated {
+		t.Fatalf("expected truncated body '0123', got '%s' (truncated: %v)", resp.Body, resp.Truncated)
+	}
+
+	for _, yml := range []string{
+		"{method: GET, maxResponseBytes: 0}",
+		"{method: GET, timeouts: {connect: 0s}}",
+	} {
+		err = yaml.Unmarshal([]byte(yml), &Client{})
+		if err == nil {
+			t.Fatalf("should have failed to parse '%s'", yml)
+		}
+	}
+}
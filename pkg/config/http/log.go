@@ -0,0 +1,194 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/creasty/defaults"
+
+	"github.com/element84/swoop-go/pkg/config/jsonpath"
+)
+
+const redacted = "[REDACTED]"
+
+type LogMode string
+
+const (
+	LogOff     LogMode = "off"
+	LogHeaders LogMode = "headers"
+	LogFull    LogMode = "full"
+)
+
+var logModes = map[LogMode]struct{}{
+	LogOff:     {},
+	LogHeaders: {},
+	LogFull:    {},
+}
+
+func (lm LogMode) String() string {
+	return string(lm)
+}
+
+func parseLogMode(s string) (LogMode, error) {
+	lm := LogMode(strings.ToLower(s))
+
+	_, ok := logModes[lm]
+	if !ok {
+		return "", fmt.Errorf("unknown log mode '%s'", s)
+	}
+
+	return lm, nil
+}
+
+func (lm *LogMode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m string
+
+	err := unmarshal(&m)
+	if err != nil {
+		return err
+	}
+
+	*lm, err = parseLogMode(m)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogConfig controls what of each request and response is recorded, and
+// what is redacted from the record. Secret values are always redacted.
+type LogConfig struct {
+	// Mode is off, headers (omitting bodies), or full
+	Mode LogMode `default:"off" yaml:"mode"`
+	// RedactHeaders replaces the default list of headers to redact
+	RedactHeaders []string `default:"[\"Authorization\",\"Cookie\",\"X-Api-Key\"]" yaml:"redactHeaders"`
+	// RedactJsonPaths are redacted from json request and response bodies
+	RedactJsonPaths []*jsonpath.JsonPath `yaml:"redactJsonPaths"`
+}
+
+func (lc *LogConfig) GetMode() LogMode {
+	if lc == nil {
+		return LogOff
+	}
+	return lc.Mode
+}
+
+// Record returns the redacted record of the request and response per the
+// log mode, or nil if logging is off. The request body must be readable
+// via GetBody, as it will have already been consumed.
+func (lc *LogConfig) Record(req *http.Request, resp *Response, secrets []string) map[string]any {
+	mode := lc.GetMode()
+	if mode == LogOff {
+		return nil
+	}
+
+	r := &redactor{lc, secrets}
+
+	request := map[string]any{
+		"method": req.Method,
+		"url":    r.secrets(req.URL.String()),
+		"proto":  req.Proto,
+		"header": r.header(req.Header),
+		"host":   r.secrets(req.Host),
+	}
+
+	var response map[string]any
+	if resp != nil {
+		response = map[string]any{
+			"StatusCode": resp.StatusCode,
+			"Header":     r.header(resp.Header),
+		}
+	}
+
+	if mode == LogFull {
+		request["body"] = r.requestBody(req)
+		if resp != nil {
+			response["Body"] = r.body(resp.Body)
+			response["Truncated"] = resp.Truncated
+		}
+	}
+
+	return map[string]any{
+		"request":  request,
+		"response": response,
+	}
+}
+
+type redactor struct {
+	conf         *LogConfig
+	secretValues []string
+}
+
+func (r *redactor) secrets(s string) string {
+	for _, secret := range r.secretValues {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
+
+func (r *redactor) header(header http.Header) http.Header {
+	h := header.Clone()
+	for name, values := range h {
+		for idx, value := range values {
+			values[idx] = r.secrets(value)
+		}
+		h[name] = values
+	}
+	for _, name := range r.conf.RedactHeaders {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, redacted)
+		}
+	}
+	return h
+}
+
+func (r *redactor) body(body string) string {
+	if len(r.conf.RedactJsonPaths) > 0 {
+		var data any
+		if json.Unmarshal([]byte(body), &data) == nil {
+			for _, path := range r.conf.RedactJsonPaths {
+				if len(path.Get(data)) > 0 {
+					_ = path.Set(data, redacted)
+				}
+			}
+			if b, err := json.Marshal(data); err == nil {
+				body = string(b)
+			}
+		}
+	}
+	return r.secrets(body)
+}
+
+func (r *redactor) requestBody(req *http.Request) *string {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	reader, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil
+	}
+
+	body := r.body(string(b))
+	return &body
+}
+
+func (lc *LogConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(lc)
+
+	type p LogConfig
+
+	return unmarshal((*p)(lc))
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/element84/swoop-go/pkg/config/jsonpath"
+	"github.com/element84/swoop-go/pkg/config/regexp"
+)
+
+type statusRange struct {
+	min int
+	max int
+}
+
+// parseStatusRange parses a status code (404), class (4xx), or
+// inclusive range (500-504)
+func parseStatusRange(s string) (statusRange, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if len(s) == 3 && strings.HasSuffix(s, "xx") {
+		class, err := strconv.Atoi(s[:1])
+		if err == nil && class >= 1 && class <= 5 {
+			return statusRange{class * 100, class*100 + 99}, nil
+		}
+	} else if lower, upper, ok := strings.Cut(s, "-"); ok {
+		min, minErr := strconv.Atoi(strings.TrimSpace(lower))
+		max, maxErr := strconv.Atoi(strings.TrimSpace(upper))
+		if minErr == nil && maxErr == nil && min <= max {
+			return statusRange{min, max}, nil
+		}
+	} else if code, err := strconv.Atoi(s); err == nil {
+		return statusRange{code, code}, nil
+	}
+
+	return statusRange{}, fmt.Errorf("invalid status code '%s'", s)
+}
+
+// statusMatcher matches any of a list of status codes, classes, and ranges
+type statusMatcher []statusRange
+
+func (sm statusMatcher) match(statusCode int) bool {
+	for _, r := range sm {
+		if statusCode >= r.min && statusCode <= r.max {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sm *statusMatcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var codes []string
+
+	err := unmarshal(&codes)
+	if err != nil {
+		var code string
+		err = unmarshal(&code)
+		if err != nil {
+			return err
+		}
+		codes = []string{code}
+	}
+
+	*sm = make(statusMatcher, 0, len(codes))
+	for _, code := range codes {
+		r, err := parseStatusRange(code)
+		if err != nil {
+			return err
+		}
+		*sm = append(*sm, r)
+	}
+
+	return nil
+}
+
+// jsonMatcher matches if any value at the path equals the given value
+// or matches the regex; non-string values are compared as json
+type jsonMatcher struct {
+	Path   *jsonpath.JsonPath `yaml:"path"`
+	Equals any                `yaml:"equals"`
+	Regex  *regexp.Regexp     `yaml:"regex"`
+	equals []byte
+}
+
+func (jm *jsonMatcher) match(data any) bool {
+	for _, value := range jm.Path.Get(data) {
+		b, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+
+		if jm.equals != nil && string(b) != string(jm.equals) {
+			continue
+		}
+
+		if jm.Regex != nil {
+			s, ok := value.(string)
+			if !ok {
+				s = string(b)
+			}
+			if !jm.Regex.MatchString(s) {
+				continue
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (jm *jsonMatcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p jsonMatcher
+
+	err := unmarshal((*p)(jm))
+	if err != nil {
+		return err
+	}
+
+	if jm.Path == nil {
+		return fmt.Errorf("json matcher requires a path")
+	}
+
+	if jm.Equals != nil {
+		jm.equals, err = json.Marshal(jm.Equals)
+		if err != nil {
+			return fmt.Errorf("json matcher equals value is not valid json: %s", err)
+		}
+	}
+
+	return nil
+}
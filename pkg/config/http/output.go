@@ -0,0 +1,53 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/element84/swoop-go/pkg/config/jsonpath"
+)
+
+// Output extracts values from a json response body, by name, to be
+// stored as the action output. Paths that match a single value give that
+// value, those that match many give a list, and those that match nothing
+// give null.
+type Output map[string]*jsonpath.JsonPath
+
+// Extract returns the output for the response, or nil if no output is
+// configured or there is no response
+func (o Output) Extract(resp *Response) map[string]any {
+	if len(o) == 0 || resp == nil {
+		return nil
+	}
+
+	output := make(map[string]any, len(o))
+	for name, path := range o {
+		values := path.Get(resp.Json)
+		switch len(values) {
+		case 0:
+			output[name] = nil
+		case 1:
+			output[name] = values[0]
+		default:
+			output[name] = values
+		}
+	}
+
+	return output
+}
+
+func (o *Output) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p Output
+
+	err := unmarshal((*p)(o))
+	if err != nil {
+		return err
+	}
+
+	for name, path := range *o {
+		if path == nil {
+			return fmt.Errorf("output '%s' requires a json path", name)
+		}
+	}
+
+	return nil
+}
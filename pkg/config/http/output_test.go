@@ -0,0 +1,43 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_Output(t *testing.T) {
+	o := Output{}
+	err := yaml.Unmarshal([]byte(`
+recordId: $.record.id
+tags: $.record.tags[*]
+missing: $.nope
+`), &o)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	resp := &Response{StatusCode: 201}
+	resp.Json, _ = decodeJson([]byte(`{"record": {"id": "abc123", "tags": ["a", "b"]}}`))
+
+	expected := map[string]any{
+		"recordId": "abc123",
+		"tags":     []any{"a", "b"},
+		"missing":  nil,
+	}
+
+	output := o.Extract(resp)
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected output %v, got %v", expected, output)
+	}
+
+	if (Output{}).Extract(resp) != nil || o.Extract(nil) != nil {
+		t.Fatal("output should be nil when not configured or without a response")
+	}
+
+	err = yaml.Unmarshal([]byte(`recordId:`), &Output{})
+	if err == nil {
+		t.Fatal("output without a path should fail to parse")
+	}
+}
@@ -2,56 +2,107 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/element84/swoop-go/pkg/config/jsonpath"
 	"github.com/element84/swoop-go/pkg/config/regexp"
+	"github.com/element84/swoop-go/pkg/errors"
 )
 
 type Response struct {
 	StatusCode int
+	Header     http.Header `json:"-"`
 	Body       string
-	Json       any `json:"-"`
+	// Truncated is set when the body exceeded the max response size
+	Truncated bool `json:",omitempty"`
+	Json      any  `json:"-"`
 }
 
-func wrapRequest(resp *http.Response, err error) (*Response, error) {
-	if err != nil {
-		return nil, err
-	}
+func readResponse(resp *http.Response, maxBytes int64) (*Response, error) {
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// we read one byte over the max so we know if the body was truncated
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
 	if err != nil {
 		return nil, err
 	}
 
+	truncated := int64(len(body)) > maxBytes
+	if truncated {
+		body = body[:maxBytes]
+	}
+
 	// TODO: check content type before trying this
+	// not a json body (or truncated), no worries, json will be nil
+	bodyJson, _ := decodeJson(body)
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+		Truncated:  truncated,
+		Json:       bodyJson,
+	}, nil
+}
+
+func decodeJson(body []byte) (map[string]any, error) {
 	bodyJson := map[string]any{}
-	err = json.Unmarshal([]byte(body), &bodyJson)
+	err := json.Unmarshal(body, &bodyJson)
 	if err != nil {
-		// not a json body, no worries, just set to nil
-		bodyJson = nil
+		return nil, err
 	}
+	return bodyJson, nil
+}
 
-	return &Response{
-		resp.StatusCode,
-		string(body),
-		bodyJson,
-	}, nil
+// retryAfter returns the seconds to wait per the Retry-After header,
+// which may be in seconds or an http date, or 0 if there is none
+func (r *Response) retryAfter(now time.Time) int {
+	value := strings.TrimSpace(r.Header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(seconds, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(int(math.Ceil(date.Sub(now).Seconds())), 0)
+	}
+
+	return 0
 }
 
 type responseMatcher struct {
-	StatusCode int                `yaml:"statusCode"`
-	JsonPath   *jsonpath.JsonPath `yaml:"jsonPath"`
-	Message    *regexp.Regexp     `yaml:"message"`
-	Result     RequestResult      `yaml:"result"`
+	// StatusCode is a code, class (4xx), range (500-504), or list of
+	// those, and is required; use 100-599 to match any status code
+	StatusCode statusMatcher `yaml:"statusCode"`
+	// Headers values must match the regexes
+	Headers  map[string]*regexp.Regexp `yaml:"headers"`
+	JsonPath *jsonpath.JsonPath        `yaml:"jsonPath"`
+	Json     []*jsonMatcher            `yaml:"json"`
+	Message  *regexp.Regexp            `yaml:"message"`
+	Result   RequestResult             `yaml:"result"`
+	// RetryAfter uses the Retry-After header, when present, as the backoff
+	// for an error result, up to the handler backoff max
+	RetryAfter bool `yaml:"retryAfter"`
 }
 
 func (rm *responseMatcher) match(resp *Response) (matched bool, err error) {
-	if rm.StatusCode != resp.StatusCode {
+	if !rm.StatusCode.match(resp.StatusCode) {
 		return false, nil
 	}
+	for name, rx := range rm.Headers {
+		if !rx.MatchString(resp.Header.Get(name)) {
+			return false, nil
+		}
+	}
 	if rm.Message != nil && !rm.Message.MatchString(resp.Body) {
 		return false, nil
 	}
@@ -60,13 +111,44 @@ func (rm *responseMatcher) match(resp *Response) (matched bool, err error) {
 			return false, nil
 		}
 	}
+	for _, jm := range rm.Json {
+		if !jm.match(resp.Json) {
+			return false, nil
+		}
+	}
 
-	return true, rm.Result.ToError()
+	err = rm.Result.ToError()
+	if re, ok := err.(*errors.RequestError); ok && rm.RetryAfter && re.Retryable {
+		re.RetryAfter = resp.retryAfter(time.Now())
+	}
+
+	return true, err
+}
+
+func (rm *responseMatcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p responseMatcher
+
+	err := unmarshal((*p)(rm))
+	if err != nil {
+		return err
+	}
+
+	// a matcher without a status code once matched nothing, so we require
+	// one rather than silently matching every status
+	if len(rm.StatusCode) == 0 {
+		return fmt.Errorf("response matcher requires a statusCode")
+	}
+
+	return nil
 }
 
 type responseChecker []*responseMatcher
 
 func (rc *responseChecker) check(resp *Response) error {
+	if rc == nil {
+		rc = &responseChecker{}
+	}
+
 	for _, matcher := range *rc {
 		matched, err := matcher.match(resp)
 		if matched {
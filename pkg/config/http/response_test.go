@@ -0,0 +1,130 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/element84/swoop-go/pkg/errors"
+)
+
+var matchersConf = `
+- statusCode: 200
+  json:
+    - path: $.status
+      equals: error
+    - path: $.detail
+      regex: "(?i)transient"
+  result: error
+- statusCode: 200
+  json:
+    - path: $.status
+      equals: error
+  result: fatal
+- statusCode: [429, 503]
+  headers:
+    Content-Type: "^application/json"
+  result: error
+  retryAfter: true
+- statusCode: 500-502
+  json:
+    - path: $.code
+      equals: 7
+  result: fatal
+- statusCode: 4xx
+  result: fatal
+`
+
+func Test_ResponseMatchers(t *testing.T) {
+	rc := &responseChecker{}
+	err := yaml.Unmarshal([]byte(matchersConf), rc)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	for _, test := range []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		expected   RequestResult
+		retryAfter int
+	}{
+		{"ok", 200, nil, `{"status": "ok"}`, Success, 0},
+		{"error payload", 200, nil, `{"status": "error"}`, Fatal, 0},
+		{"transient error payload", 200, nil, `{"status": "error", "detail": "a Transient problem"}`, Error, 0},
+		{"list", 503, jsonHeader, `{}`, Error, 0},
+		{"retry after", 429, http.Header{"Content-Type": {"application/json"}, "Retry-After": {"120"}}, `{}`, Error, 120},
+		{"header mismatch", 429, http.Header{"Retry-After": {"120"}}, ``, Fatal, 0},
+		{"range", 501, nil, `{"code": 7}`, Fatal, 0},
+		{"range value mismatch", 501, nil, `{"code": 8}`, Error, 0},
+		{"class", 404, nil, ``, Fatal, 0},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				resp := &Response{StatusCode: test.status, Header: test.header, Body: test.body}
+				if resp.Header == nil {
+					resp.Header = http.Header{}
+				}
+				resp.Json, _ = decodeJson([]byte(test.body))
+
+				err := rc.check(resp)
+				result, ok := RequestResultFromError(err)
+				if !ok {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if result != test.expected {
+					t.Fatalf("expected result '%s', got '%s'", test.expected, result)
+				}
+
+				if re, ok := err.(*errors.RequestError); ok && re.RetryAfter != test.retryAfter {
+					t.Fatalf("expected retry after %d, got %d", test.retryAfter, re.RetryAfter)
+				}
+			},
+		)
+	}
+}
+
+func Test_ResponseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		value    string
+		expected int
+	}{
+		{"", 0},
+		{"30", 30},
+		{"-5", 0},
+		{"soon", 0},
+		{"Thu, 01 Jun 2023 12:01:30 GMT", 90},
+		{"Thu, 01 Jun 2023 11:00:00 GMT", 0},
+	} {
+		resp := &Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", test.value)
+
+		if seconds := resp.retryAfter(now); seconds != test.expected {
+			t.Fatalf("expected %d seconds for '%s', got %d", test.expected, test.value, seconds)
+		}
+	}
+}
+
+func Test_ResponseMatchersBad(t *testing.T) {
+	for _, yml := range []string{
+		"[{statusCode: 6xx, result: error}]",
+		"[{statusCode: 500-400, result: error}]",
+		"[{statusCode: [200, abc], result: error}]",
+		"[{statusCode: 200, json: [{equals: 1}], result: error}]",
+		"[{json: [{path: $.status, equals: error}], result: error}]",
+		"[{statusCode: [], result: error}]",
+	} {
+		err := yaml.Unmarshal([]byte(yml), &responseChecker{})
+		if err == nil {
+			t.Fatalf("should have failed to parse '%s'", yml)
+		}
+	}
+}
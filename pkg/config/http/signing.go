@@ -0,0 +1,229 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/creasty/defaults"
+
+	"github.com/element84/swoop-go/pkg/config/template"
+	"github.com/element84/swoop-go/pkg/errors"
+)
+
+type SigningType string
+
+const (
+	HmacSigning  SigningType = "hmac"
+	SigV4Signing SigningType = "sigv4"
+)
+
+var signingTypes = map[SigningType]struct{}{
+	HmacSigning:  {},
+	SigV4Signing: {},
+}
+
+func (st SigningType) String() string {
+	return string(st)
+}
+
+func parseSigningType(s string) (SigningType, error) {
+	st := SigningType(s)
+
+	_, ok := signingTypes[st]
+	if !ok {
+		return "", fmt.Errorf("unknown signing type '%s'", s)
+	}
+
+	return st, nil
+}
+
+func (st *SigningType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+
+	*st, err = parseSigningType(s)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SigningConfig signs requests so the receiver can verify they came from
+// swoop. The hmac type signs the timestamp and body with a shared key, in
+// the form "<timestamp>.<body>". The sigv4 type signs with AWS credentials
+// from the environment, e.g. for API Gateway or Lambda function urls.
+type SigningConfig struct {
+	Type SigningType `yaml:"type"`
+
+	// Key is the hmac key, usually taken from the handler secrets
+	Key             *template.Template `yaml:"key"`
+	SignatureHeader string             `default:"X-Swoop-Signature" yaml:"signatureHeader"`
+	TimestampHeader string             `default:"X-Swoop-Timestamp" yaml:"timestampHeader"`
+
+	// Service and Region are the sigv4 signing scope
+	Service string `default:"execute-api" yaml:"service"`
+	Region  string `yaml:"region"`
+
+	signerMu sync.Mutex
+	signer   *v4.Signer
+	// region is Region, or if unset the region from the environment
+	region string
+}
+
+func (sc *SigningConfig) key(data any) (string, error) {
+	if sc == nil || sc.Type != HmacSigning {
+		return "", nil
+	}
+	return sc.Key.ExecuteToString(data)
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return []byte{}, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+func (sc *SigningConfig) hmacSign(req *http.Request, key string, now time.Time) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	req.Header.Set(sc.TimestampHeader, timestamp)
+	req.Header.Set(sc.SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// getSigner creates the sigv4 signer on first use. Failures are not kept,
+// so a transient failure getting credentials is retried on the next request.
+func (sc *SigningConfig) getSigner() (*v4.Signer, string, error) {
+	sc.signerMu.Lock()
+	defer sc.signerMu.Unlock()
+
+	if sc.signer != nil {
+		return sc.signer, sc.region, nil
+	}
+
+	// see https://pkg.go.dev/github.com/aws/aws-sdk-go/aws/session
+	// for details on how this gets creds and the supported env vars
+	awsConf := aws.Config{}
+	if sc.Region != "" {
+		awsConf.Region = aws.String(sc.Region)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsConf,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	region := sc.Region
+	if region == "" && sess.Config.Region != nil {
+		region = *sess.Config.Region
+	}
+	if region == "" {
+		return nil, "", fmt.Errorf("sigv4 signing requires a region")
+	}
+
+	sc.signer = v4.NewSigner(sess.Config.Credentials)
+	sc.region = region
+	return sc.signer, sc.region, nil
+}
+
+func (sc *SigningConfig) sigv4Sign(req *http.Request, now time.Time) error {
+	signer, region, err := sc.getSigner()
+	if err != nil {
+		return err
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = signer.Sign(req, bytes.NewReader(body), sc.Service, region, now)
+	return err
+}
+
+// sign adds the signature headers to the outgoing request; failures are
+// retryable, as signing may fail while credentials are being refreshed
+func (sc *SigningConfig) sign(req *http.Request, key string) error {
+	if sc == nil {
+		return nil
+	}
+
+	var err error
+	switch sc.Type {
+	case HmacSigning:
+		err = sc.hmacSign(req, key, time.Now())
+	case SigV4Signing:
+		err = sc.sigv4Sign(req, time.Now())
+	}
+
+	if err != nil {
+		return errors.NewRequestError(fmt.Errorf("failed to sign request: %s", err), true)
+	}
+	return nil
+}
+
+func (sc *SigningConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(sc)
+
+	type p SigningConfig
+
+	err := unmarshal((*p)(sc))
+	if err != nil {
+		return err
+	}
+
+	switch sc.Type {
+	case HmacSigning:
+		if sc.Key == nil {
+			return fmt.Errorf("hmac signing requires a key")
+		}
+		if sc.SignatureHeader == "" || sc.TimestampHeader == "" {
+			return fmt.Errorf("hmac signing headers must not be empty")
+		}
+	case SigV4Signing:
+		if sc.Service == "" {
+			return fmt.Errorf("sigv4 signing requires a service")
+		}
+	default:
+		return fmt.Errorf("signing type is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_SigningHmac(t *testing.T) {
+	key := "swoop-signing-key"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-Timestamp")
+
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(timestamp + "." + string(body)))
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		if timestamp == "" || !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Swoop-Signature"))) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	hr := &Client{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+url: %s
+method: POST
+body: '{"id": "{{ .uuid }}"}'
+signing:
+  type: hmac
+  key: "{{ .secrets.signingKey }}"
+  timestampHeader: X-Timestamp
+`, ts.URL)), hr)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	for _, test := range []struct {
+		name     string
+		key      string
+		expected RequestResult
+	}{
+		{"valid", key, Success},
+		{"wrong key", "not-the-key", Error},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				req, err := hr.NewRequest(map[string]any{
+					"uuid":    "abc",
+					"secrets": map[string]string{"signingKey": test.key},
+				})
+				if err != nil {
+					t.Fatalf("error templating request: %s", err)
+				}
+
+				_, err = hr.MakeRequest(context.Background(), req)
+				result, ok := RequestResultFromError(err)
+				if !ok || result != test.expected {
+					t.Fatalf("expected result '%s', got: %v", test.expected, err)
+				}
+			},
+		)
+	}
+}
+
+func Test_SigningSigV4(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	t.Cleanup(ts.Close)
+
+	hr := &Client{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+url: %s
+method: POST
+body: '{}'
+signing:
+  type: sigv4
+  service: lambda
+  region: us-west-2
+`, ts.URL)), hr)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	req, err := hr.NewRequest(map[string]any{})
+	if err != nil {
+		t.Fatalf("error templating request: %s", err)
+	}
+
+	_, err = hr.MakeRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+
+	auth := header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(auth, "/us-west-2/lambda/aws4_request") {
+		t.Fatalf("unexpected sigv4 authorization header: '%s'", auth)
+	}
+	if header.Get("X-Amz-Date") == "" {
+		t.Fatal("sigv4 signing should set X-Amz-Date")
+	}
+}
+
+func Test_SigningSigV4Retry(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	sc := &SigningConfig{Type: SigV4Signing, Service: "lambda"}
+
+	_, _, err := sc.getSigner()
+	if err == nil {
+		t.Fatal("expected creating a signer without a region to fail")
+	}
+
+	// the failure is not kept, so a later call can succeed
+	t.Setenv("AWS_REGION", "us-west-2")
+
+	signer, region, err := sc.getSigner()
+	if err != nil {
+		t.Fatalf("failed to create signer: %s", err)
+	}
+	if signer == nil || region != "us-west-2" {
+		t.Fatalf("unexpected signer region '%s'", region)
+	}
+	if sc.Region != "" {
+		t.Fatalf("expected configured region to be unchanged, got '%s'", sc.Region)
+	}
+}
+
+func Test_SigningConfig(t *testing.T) {
+	for _, yml := range []string{
+		"{}",
+		"{type: rsa}",
+		"{type: hmac}",
+		"{type: hmac, key: k, signatureHeader: ''}",
+		"{type: sigv4, service: ''}",
+	} {
+		err := yaml.Unmarshal([]byte(yml), &SigningConfig{})
+		if err == nil {
+			t.Fatalf("should have failed to parse '%s'", yml)
+		}
+	}
+
+	err := yaml.Unmarshal([]byte(`
+method: GET
+auth: {type: clientCredentials, tokenUrl: 'https://a', clientId: a, clientSecret: b}
+signing: {type: sigv4, region: us-west-2}
+`), &Client{})
+	if err == nil {
+		t.Fatal("auth and sigv4 signing should not be allowed together")
+	}
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/creasty/defaults"
+)
+
+type TLSConfig struct {
+	// CertFile and KeyFile are the client certificate for mutual TLS
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// CAFile is a PEM bundle of CAs to trust instead of the system roots
+	CAFile             string `yaml:"caFile"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+func (tc *TLSConfig) build() (*tls.Config, error) {
+	conf := &tls.Config{
+		InsecureSkipVerify: tc.InsecureSkipVerify,
+	}
+
+	if (tc.CertFile == "") != (tc.KeyFile == "") {
+		return nil, fmt.Errorf("tls certFile and keyFile must be specified together")
+	}
+
+	if tc.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls client certificate: %s", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	if tc.CAFile != "" {
+		pem, err := os.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls caFile: %s", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in tls caFile '%s'", tc.CAFile)
+		}
+		conf.RootCAs = pool
+	}
+
+	return conf, nil
+}
+
+type TransportConfig struct {
+	TLS *TLSConfig `yaml:"tls"`
+	// Proxy is the url of an HTTP(S) proxy; if unset, the proxy
+	// is taken from the standard environment variables
+	Proxy string `yaml:"proxy"`
+	// MaxIdleConns limits idle connections across all hosts
+	MaxIdleConns int  `default:"100" yaml:"maxIdleConns"`
+	HTTP2        bool `default:"true" yaml:"http2"`
+
+	transport *http.Transport
+}
+
+// Transport returns the configured transport, or a default transport
+// if no transport is configured
+func (tc *TransportConfig) Transport() *http.Transport {
+	if tc == nil {
+		return http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return tc.transport
+}
+
+func (tc *TransportConfig) build() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = tc.MaxIdleConns
+
+	if tc.TLS != nil {
+		tlsConf, err := tc.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConf
+	}
+
+	if tc.Proxy != "" {
+		proxy, err := url.Parse(tc.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy is not a valid url: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if !tc.HTTP2 {
+		// a non-nil, empty map disables HTTP/2 per the net/http docs
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport, nil
+}
+
+func (tc *TransportConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	defaults.Set(tc)
+
+	type p TransportConfig
+
+	err := unmarshal((*p)(tc))
+	if err != nil {
+		return err
+	}
+
+	if tc.MaxIdleConns < 0 {
+		return fmt.Errorf("maxIdleConns must not be negative, got '%d'", tc.MaxIdleConns)
+	}
+
+	tc.transport, err = tc.build()
+	return err
+}
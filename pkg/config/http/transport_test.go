@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writePem(t *testing.T, path, blockType string, der []byte) {
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write '%s': %s", path, err)
+	}
+}
+
+// mkClientCert writes a self-signed client certificate and key
+func mkClientCert(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "swoop-test-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	writePem(t, certFile, "CERTIFICATE", der)
+	writePem(t, keyFile, "EC PRIVATE KEY", keyDer)
+
+	return cert, certFile, keyFile
+}
+
+func Test_TransportMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, certFile, keyFile := mkClientCert(t, dir)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	writePem(t, caFile, "CERTIFICATE", ts.Certificate().Raw)
+
+	for _, test := range []struct {
+		name    string
+		tls     string
+		success bool
+	}{
+		{"no client cert", fmt.Sprintf("{caFile: %s}", caFile), false},
+		{"untrusted server", fmt.Sprintf("{certFile: %s, keyFile: %s}", certFile, keyFile), false},
+		{"mutual", fmt.Sprintf("{caFile: %s, certFile: %s, keyFile: %s}", caFile, certFile, keyFile), true},
+		{"insecure", fmt.Sprintf("{insecureSkipVerify: true, certFile: %s, keyFile: %s}", certFile, keyFile), true},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				hr := &Client{}
+				err := yaml.Unmarshal([]byte(fmt.Sprintf("{method: GET, transport: {tls: %s}}", test.tls)), hr)
+				if err != nil {
+					t.Fatalf("error parsing yaml: %s", err)
+				}
+
+				req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+				resp, err := hr.MakeRequest(context.Background(), &Request{Request: req})
+				if !test.success {
+					if err == nil {
+						t.Fatal("request should have failed")
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("request failed: %s", err)
+				}
+				if resp.Body != "swoop-test-client" {
+					t.Fatalf("unexpected response: '%s'", resp.Body)
+				}
+			},
+		)
+	}
+}
+
+func Test_TransportProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Host)
+	}))
+	t.Cleanup(proxy.Close)
+
+	hr := &Client{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf("{method: GET, transport: {proxy: '%s'}}", proxy.URL)), hr)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://swoop.invalid", nil)
+	resp, err := hr.MakeRequest(context.Background(), &Request{Request: req})
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	if resp.Body != "swoop.invalid" {
+		t.Fatalf("request should have gone via the proxy, got '%s'", resp.Body)
+	}
+}
+
+func Test_TransportConfig(t *testing.T) {
+	tc := &TransportConfig{}
+	err := yaml.Unmarshal([]byte(`http2: false`), tc)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	transport := tc.Transport()
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Fatal("http2 should be disabled")
+	}
+	if transport.MaxIdleConns != 100 {
+		t.Fatalf("expected default max idle conns, got %d", transport.MaxIdleConns)
+	}
+
+	for _, yml := range []string{
+		"{tls: {certFile: a.crt}}",
+		"{tls: {caFile: /nonexistent}}",
+		"{maxIdleConns: -1}",
+	} {
+		err = yaml.Unmarshal([]byte(yml), &TransportConfig{})
+		if err == nil {
+			t.Fatalf("should have failed to parse '%s'", yml)
+		}
+	}
+}
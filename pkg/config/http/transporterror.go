@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+
+	swooperrs "github.com/element84/swoop-go/pkg/errors"
+)
+
+// ErrorClass is the kind of failure when a request could not be made
+type ErrorClass string
+
+const (
+	DnsError               ErrorClass = "dns"
+	ConnectionRefusedError ErrorClass = "connectionRefused"
+	TimeoutError           ErrorClass = "timeout"
+	TlsError               ErrorClass = "tls"
+	InvalidUrlError        ErrorClass = "invalidUrl"
+	CanceledError          ErrorClass = "canceled"
+	UnknownError           ErrorClass = "unknown"
+)
+
+// default result per error class; anything likely to resolve on its own
+// is retried, whereas configuration problems are not
+var defaultErrorResults = map[ErrorClass]RequestResult{
+	DnsError:               Error,
+	ConnectionRefusedError: Error,
+	TimeoutError:           Error,
+	TlsError:               Fatal,
+	InvalidUrlError:        Fatal,
+	CanceledError:          Error,
+	UnknownError:           Error,
+}
+
+func (ec ErrorClass) String() string {
+	return string(ec)
+}
+
+func parseErrorClass(s string) (ErrorClass, error) {
+	ec := ErrorClass(s)
+
+	_, ok := defaultErrorResults[ec]
+	if !ok {
+		return "", fmt.Errorf("unknown error class '%s'", s)
+	}
+
+	return ec, nil
+}
+
+func (ec *ErrorClass) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var c string
+
+	err := unmarshal(&c)
+	if err != nil {
+		return err
+	}
+
+	*ec, err = parseErrorClass(c)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func classifyError(err error) ErrorClass {
+	var (
+		dnsErr       *net.DNSError
+		netErr       net.Error
+		urlErr       *url.Error
+		verifyErr    *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return CanceledError
+	case errors.Is(err, context.DeadlineExceeded):
+		return TimeoutError
+	case errors.As(err, &dnsErr):
+		return DnsError
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ConnectionRefusedError
+	case errors.As(err, &verifyErr),
+		errors.As(err, &recordErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return TlsError
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return TimeoutError
+	case errors.As(err, &urlErr) && errors.Is(urlErr.Err, errInvalidUrl):
+		return InvalidUrlError
+	}
+
+	return UnknownError
+}
+
+var errInvalidUrl = errors.New("invalid url")
+
+// checkUrl catches urls the client would reject before making a
+// connection, as the client does not return a distinct error for them
+func checkUrl(u *url.URL) error {
+	var err error
+	if u.Scheme != "http" && u.Scheme != "https" {
+		err = fmt.Errorf("%w: unsupported scheme '%s'", errInvalidUrl, u.Scheme)
+	} else if u.Host == "" {
+		err = fmt.Errorf("%w: missing host", errInvalidUrl)
+	}
+
+	if err != nil {
+		return &url.Error{Op: "Check", URL: u.Redacted(), Err: err}
+	}
+	return nil
+}
+
+// TransportErrors overrides the result for classes of request errors
+type TransportErrors map[ErrorClass]RequestResult
+
+func (te TransportErrors) Validate() error {
+	for class, result := range te {
+		if result == Success {
+			return fmt.Errorf("transport error '%s' cannot be a success", class)
+		}
+	}
+	return nil
+}
+
+// toRequestError wraps an error from making a request per its class
+func (te TransportErrors) toRequestError(err error) *swooperrs.RequestError {
+	class := classifyError(err)
+
+	result, ok := te[class]
+	if !ok {
+		result = defaultErrorResults[class]
+	}
+
+	return swooperrs.NewRequestError(
+		fmt.Errorf("%s error making request: %w", class, err),
+		result != Fatal,
+	)
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	swooperrs "github.com/element84/swoop-go/pkg/errors"
+)
+
+func Test_TransportErrors(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(tlsServer.Close)
+
+	// get a port nothing is listening on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	refusedUrl := "http://" + listener.Addr().String()
+	listener.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, test := range []struct {
+		name      string
+		url       string
+		ctx       context.Context
+		class     ErrorClass
+		retryable bool
+	}{
+		{"dns", "http://swoop.invalid", context.Background(), DnsError, true},
+		{"refused", refusedUrl, context.Background(), ConnectionRefusedError, true},
+		{"tls", tlsServer.URL, context.Background(), TlsError, false},
+		{"scheme", "ftp://example.com", context.Background(), InvalidUrlError, false},
+		{"host", "http:///path", context.Background(), InvalidUrlError, false},
+		{"canceled", tlsServer.URL, canceled, CanceledError, true},
+	} {
+		t.Run(
+			test.name,
+			func(t *testing.T) {
+				client := &Client{}
+				err := yaml.Unmarshal([]byte(`method: GET`), client)
+				if err != nil {
+					t.Fatalf("error parsing yaml: %s", err)
+				}
+
+				req, err := http.NewRequest(http.MethodGet, test.url, nil)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+
+				_, err = client.MakeRequest(test.ctx, &Request{Request: req})
+				if err == nil {
+					t.Fatal("request should have failed")
+				}
+
+				if class := classifyError(errors.Unwrap(err)); class != test.class {
+					t.Fatalf("expected error class '%s', got '%s': %s", test.class, class, err)
+				}
+
+				var re *swooperrs.RequestError
+				if !errors.As(err, &re) || re.Retryable != test.retryable {
+					t.Fatalf("expected retryable to be %v, got: %#v", test.retryable, err)
+				}
+			},
+		)
+	}
+}
+
+func Test_TransportErrorsOverride(t *testing.T) {
+	client := &Client{}
+	err := yaml.Unmarshal([]byte(`
+method: GET
+transportErrors:
+  invalidUrl: error
+  dns: fatal
+`), client)
+	if err != nil {
+		t.Fatalf("error parsing yaml: %s", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "ftp://example.com", nil)
+	_, err = client.MakeRequest(context.Background(), &Request{Request: req})
+
+	result, ok := RequestResultFromError(err)
+	if !ok || result != Error {
+		t.Fatalf("expected overridden result '%s', got '%s'", Error, result)
+	}
+
+	for _, yml := range []string{
+		"{method: GET, transportErrors: {tls: success}}",
+		"{method: GET, transportErrors: {nope: fatal}}",
+	} {
+		err = yaml.Unmarshal([]byte(yml), &Client{})
+		if err == nil {
+			t.Fatalf("should have failed to parse '%s'", yml)
+		}
+	}
+}
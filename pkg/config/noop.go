@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+type NoopConf struct {
+	// Delay is how long each action takes to "run"
+	Delay time.Duration `yaml:"delay"`
+	// FailureRatio is the fraction of actions that fail, as a retryable error
+	FailureRatio float64 `yaml:"failureRatio"`
+}
+
+func (nc *NoopConf) Validate() error {
+	if nc.Delay < 0 {
+		return fmt.Errorf("delay must not be negative, got '%s'", nc.Delay)
+	}
+
+	if nc.FailureRatio < 0 || nc.FailureRatio > 1 {
+		return fmt.Errorf("failureRatio must be between 0 and 1, got '%v'", nc.FailureRatio)
+	}
+
+	return nil
+}
+
+func (nc *NoopConf) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p NoopConf
+
+	err := unmarshal((*p)(nc))
+	if err != nil {
+		return err
+	}
+
+	return nc.Validate()
+}
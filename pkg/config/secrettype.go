@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SecretType string
+
+const (
+	FileSecret  SecretType = "file"
+	EnvSecret   SecretType = "env"
+	K8sSecret   SecretType = "k8s"
+	VaultSecret SecretType = "vault"
+)
+
+var SecretTypes = map[SecretType]struct{}{
+	FileSecret:  {},
+	EnvSecret:   {},
+	K8sSecret:   {},
+	VaultSecret: {},
+}
+
+func (st SecretType) String() string {
+	return string(st)
+}
+
+func ParseSecretType(s string) (SecretType, error) {
+	st := SecretType(strings.ToLower(s))
+
+	_, ok := SecretTypes[st]
+	if !ok {
+		return "", fmt.Errorf("unknown secret type '%s'", s)
+	}
+
+	return st, nil
+}
+
+func (st *SecretType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var sType string
+
+	err := unmarshal(&sType)
+	if err != nil {
+		return err
+	}
+
+	*st, err = ParseSecretType(sType)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
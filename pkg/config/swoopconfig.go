@@ -27,19 +27,40 @@ func (cs Conductors) setHandlers(handlers Handlers) error {
 }
 
 type Conductor struct {
-	HandlerNames []string   `yaml:"handlers,omitempty"`
-	Handlers     []*Handler `yaml:"-"`
+	HandlerNames []string           `yaml:"handlers,omitempty"`
+	Settings     *ConductorSettings `yaml:"settings"`
+	Handlers     []*Handler         `yaml:"-"`
+}
+
+func (c *Conductor) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type p Conductor
+
+	err := unmarshal((*p)(c))
+	if err != nil {
+		return err
+	}
+
+	if c.Settings == nil {
+		c.Settings = NewConductorSettings()
+	}
+
+	return nil
 }
 
 type SwoopConfig struct {
 	Workflows  Workflows  `yaml:"workflows"`
 	Handlers   Handlers   `yaml:"handlers"`
 	Conductors Conductors `yaml:"conductors"`
+	Caboose    *Caboose   `yaml:"caboose"`
 }
 
 func (sc *SwoopConfig) LinkAndValidate() error {
 	// TODO: testing
 
+	if sc.Caboose == nil {
+		sc.Caboose = NewCaboose()
+	}
+
 	err := sc.Conductors.setHandlers(sc.Handlers)
 	if err != nil {
 		return err
@@ -2,24 +2,46 @@ package db
 
 import (
 	"context"
+	"errors"
 
 	"github.com/gofrs/uuid/v5"
 )
 
 type CallbackAction struct {
+	actionUuid   uuid.UUID
 	callbackName string
 	handlerName  string
 	handlerType  string
 	workflowUuid uuid.UUID
 }
 
+// NewCallbackAction creates a callback action the database will assign a uuid
 func NewCallbackAction(
 	callbackName string,
 	handlerName string,
 	handlerType string,
 	workflowUuid uuid.UUID,
+) *CallbackAction {
+	return NewCallbackActionWithUuid(
+		uuid.Nil,
+		callbackName,
+		handlerName,
+		handlerType,
+		workflowUuid,
+	)
+}
+
+// NewCallbackActionWithUuid creates a callback action with a known uuid,
+// which is only inserted if no action with that uuid already exists
+func NewCallbackActionWithUuid(
+	actionUuid uuid.UUID,
+	callbackName string,
+	handlerName string,
+	handlerType string,
+	workflowUuid uuid.UUID,
 ) *CallbackAction {
 	return &CallbackAction{
+		actionUuid,
 		callbackName,
 		handlerName,
 		handlerType,
@@ -28,6 +50,14 @@ func NewCallbackAction(
 }
 
 func (cba *CallbackAction) Insert(ctx context.Context, conn Conn) (uuid.UUID, error) {
+	if !cba.actionUuid.IsNil() {
+		_, err := cba.InsertWithUuid(ctx, conn)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return cba.actionUuid, nil
+	}
+
 	var id uuid.UUID
 
 	err := conn.QueryRow(
@@ -56,3 +86,44 @@ func (cba *CallbackAction) Insert(ctx context.Context, conn Conn) (uuid.UUID, er
 
 	return id, nil
 }
+
+// InsertWithUuid inserts an action with a known uuid, returning false if
+// an action with that uuid already exists. We check for an existing action
+// explicitly rather than using ON CONFLICT, as a partitioned action table
+// need not have a unique action_uuid index.
+func (cba *CallbackAction) InsertWithUuid(ctx context.Context, conn Conn) (bool, error) {
+	if cba.actionUuid.IsNil() {
+		return false, errors.New("callback action has no uuid")
+	}
+
+	tag, err := conn.Exec(
+		ctx,
+		`INSERT INTO swoop.action (
+			action_uuid,
+			action_type,
+			action_name,
+			handler_name,
+			handler_type,
+			parent_uuid
+		) SELECT
+			$1,
+			'callback',
+			$2,
+			$3,
+			$4,
+			$5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM swoop.action WHERE action_uuid = $1
+		)`,
+		cba.actionUuid,
+		cba.callbackName,
+		cba.handlerName,
+		cba.handlerType,
+		cba.workflowUuid,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -4,12 +4,32 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	pgxuuid "github.com/jackc/pgx-gofrs-uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/element84/swoop-go/pkg/utils"
+)
+
+const (
+	MinReconnectBackoff = 1 * time.Second
+	MaxReconnectBackoff = 60 * time.Second
 )
 
+// ReconnectBackoff returns how long to wait before the given
+// (zero-indexed) reconnection attempt, doubling from
+// MinReconnectBackoff up to MaxReconnectBackoff.
+func ReconnectBackoff(attempt int) time.Duration {
+	// cap the exponent, anything larger is well over the max anyway
+	backoff := time.Duration(utils.IntPow(2, min(attempt, 16))) * MinReconnectBackoff
+	if backoff > MaxReconnectBackoff {
+		return MaxReconnectBackoff
+	}
+	return backoff
+}
+
 type ConnectConfig struct {
 	// we could in the future support more config parameters, see
 	// https://pkg.go.dev/github.com/jackc/pgx/v5/pgxpool#Config
@@ -43,6 +63,8 @@ func (conf *ConnectConfig) Connect(ctx context.Context) (*pgx.Conn, error) {
 
 type PoolConfig struct {
 	ConnectConfig
+	// MaxConns limits the size of the pool, if positive
+	MaxConns int32
 }
 
 func (conf *PoolConfig) Connect(ctx context.Context) (*pgxpool.Pool, error) {
@@ -51,6 +73,10 @@ func (conf *PoolConfig) Connect(ctx context.Context) (*pgxpool.Pool, error) {
 		return nil, err
 	}
 
+	if conf.MaxConns > 0 {
+		config.MaxConns = conf.MaxConns
+	}
+
 	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
 		pgxuuid.Register(conn.TypeMap())
 		return nil
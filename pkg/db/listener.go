@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -14,30 +15,81 @@ import (
 type Notifiable interface {
 	GetName() string
 	Notify()
+	// NotifyNow is called for all notifiables after reconnecting,
+	// as any notifications while disconnected will have been missed
+	NotifyNow()
 }
 
-func listen(ctx context.Context, conn *pgx.Conn, notifMap map[string]Notifiable) {
+type listener struct {
+	config   *ConnectConfig
+	notifMap map[string]Notifiable
+	sql      string
+}
+
+func (l *listener) connect(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := l.config.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, l.sql)
+	if err != nil {
+		conn.Close(ctx)
+		// TODO: abstract this error handling and use elsewhere
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			log.Printf("%+v", err)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// reconnect tries to connect until successful, backing off between
+// attempts; returns nil if the context is done before connecting
+func (l *listener) reconnect(ctx context.Context) *pgx.Conn {
+	for attempt := 0; ; attempt++ {
+		conn, err := l.connect(ctx)
+		if err == nil {
+			log.Printf("listener reconnected")
+			return conn
+		}
+
+		backoff := ReconnectBackoff(attempt)
+		log.Printf("listener failed to reconnect, retrying in %s: %s", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// listen handles notifications until the connection closes or ctx is done
+func (l *listener) listen(ctx context.Context, conn *pgx.Conn) error {
 	defer conn.Close(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return nil
 		default:
 		}
 
 		notification, err := conn.WaitForNotification(ctx)
 		if err != nil {
-			log.Printf("error while waiting for pg notification: %s", err)
 			if conn.IsClosed() {
 				// any fatal errors will close the connection per
 				// https://github.com/jackc/pgx/blob/8fb309c6317483733c783e9f9a4ac09cb8271849/pgconn/pgconn.go#L515
-				return
+				return err
 			}
+			log.Printf("error while waiting for pg notification: %s", err)
 			continue
 		}
 
-		notifiable, ok := notifMap[notification.Channel]
+		notifiable, ok := l.notifMap[notification.Channel]
 		if !ok {
 			log.Printf("notification received for unknown channel '%s'", notification.Channel)
 			continue
@@ -47,22 +99,32 @@ func listen(ctx context.Context, conn *pgx.Conn, notifMap map[string]Notifiable)
 	}
 }
 
-func Listen[T Notifiable](ctx context.Context, config *ConnectConfig, notifiables []T) error {
-	listening := false
+func (l *listener) run(ctx context.Context, conn *pgx.Conn) {
+	for {
+		err := l.listen(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
 
-	if len(notifiables) == 0 {
-		return fmt.Errorf("not listening: nothing to listen to")
+		log.Printf("listener connection lost, reconnecting: %s", err)
+		conn = l.reconnect(ctx)
+		if conn == nil {
+			return
+		}
+
+		for _, notifiable := range l.notifMap {
+			notifiable.NotifyNow()
+		}
 	}
+}
 
-	conn, err := config.Connect(ctx)
-	if err != nil {
-		return err
+// Listen starts listening for notifications on a channel per notifiable,
+// returning once listening has started. If the connection is lost then it
+// will be re-established and all notifiables forced to poll.
+func Listen[T Notifiable](ctx context.Context, config *ConnectConfig, notifiables []T) error {
+	if len(notifiables) == 0 {
+		return fmt.Errorf("not listening: nothing to listen to")
 	}
-	defer func() {
-		if !listening {
-			conn.Close(ctx)
-		}
-	}()
 
 	notifMap := map[string]Notifiable{}
 	sqlStmts := []string{}
@@ -72,18 +134,18 @@ func Listen[T Notifiable](ctx context.Context, config *ConnectConfig, notifiable
 		sqlStmts = append(sqlStmts, fmt.Sprintf(`LISTEN "%s";`, name))
 	}
 
-	_, err = conn.Exec(ctx, strings.Join(sqlStmts[:], "\n"))
+	l := &listener{
+		config:   config,
+		notifMap: notifMap,
+		sql:      strings.Join(sqlStmts[:], "\n"),
+	}
+
+	conn, err := l.connect(ctx)
 	if err != nil {
-		// TODO: abstract this error handling and use elsewhere
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			log.Printf("%+v", err)
-		}
 		return err
 	}
 
-	go listen(ctx, conn, notifMap)
-	listening = true
+	go l.run(ctx, conn)
 
 	return nil
 }
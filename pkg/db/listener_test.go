@@ -7,11 +7,13 @@ import (
 	"time"
 
 	. "github.com/element84/swoop-go/pkg/db"
+	"github.com/element84/swoop-go/pkg/utils/testing/proxy"
 )
 
 type TestHandler struct {
 	name    string
 	channel chan string
+	forced  chan string
 }
 
 func (t *TestHandler) GetName() string {
@@ -22,6 +24,16 @@ func (t *TestHandler) Notify() {
 	t.channel <- t.name
 }
 
+func (t *TestHandler) NotifyNow() {
+	if t.forced == nil {
+		return
+	}
+	select {
+	case t.forced <- t.name:
+	default:
+	}
+}
+
 func TestListener(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer func() {
@@ -110,3 +122,63 @@ func TestListenerNoHandlers(t *testing.T) {
 		t.Fatalf("unexpected error: '%s'; wanted: '%s'", err, expected)
 	}
 }
+
+func TestListenerReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	dbconf := &ConnectConfig{}
+
+	// the notifier connects directly, only the listener goes via the proxy
+	notifierConn, err := dbconf.Connect(ctx)
+	if err != nil {
+		t.Fatalf("failed to create database connection for notifier: %s", err)
+	}
+	defer notifierConn.Close(ctx)
+
+	p := proxy.NewPostgresProxy(t)
+	t.Setenv("PGHOST", p.Host())
+	t.Setenv("PGPORT", p.Port())
+
+	notifications := make(chan string, 1)
+	forced := make(chan string, 1)
+	handlers := []*TestHandler{
+		{name: "h1", channel: notifications, forced: forced},
+	}
+
+	err = Listen(ctx, dbconf, handlers)
+	if err != nil {
+		t.Fatalf("listening failed: %s", err)
+	}
+
+	receive := func(c chan string, timeout time.Duration) string {
+		select {
+		case <-time.After(timeout):
+			return "[timed out]"
+		case msg := <-c:
+			return msg
+		}
+	}
+
+	sendReceive := func() string {
+		_, err := notifierConn.Exec(ctx, "select pg_notify('h1', 'h1')")
+		if err != nil {
+			t.Fatalf("failed to notify: %s", err)
+		}
+		return receive(notifications, 1*time.Second)
+	}
+
+	if received := sendReceive(); received != "h1" {
+		t.Fatalf("expected notification before cut, got '%s'", received)
+	}
+
+	p.Cut()
+
+	if received := receive(forced, 10*time.Second); received != "h1" {
+		t.Fatalf("expected forced notification on reconnect, got '%s'", received)
+	}
+
+	if received := sendReceive(); received != "h1" {
+		t.Fatalf("expected notification after reconnect, got '%s'", received)
+	}
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SyncConn wraps a Conn to allow its use from multiple goroutines by
+// serializing all operations. Query holds the connection until the
+// returned Rows are closed, and Begin holds it until the transaction is
+// committed or rolled back, so transactions should be kept short.
+// QueryRow reads its row before returning, so the Row need not be scanned.
+type SyncConn struct {
+	mu   sync.Mutex
+	conn Conn
+}
+
+func NewSyncConn(conn Conn) *SyncConn {
+	return &SyncConn{conn: conn}
+}
+
+func (sc *SyncConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	sc.mu.Lock()
+	tx, err := sc.conn.Begin(ctx)
+	if err != nil {
+		sc.mu.Unlock()
+		return nil, err
+	}
+	return &syncTx{Tx: tx, unlock: sc.unlocker()}, nil
+}
+
+func (sc *SyncConn) Exec(
+	ctx context.Context,
+	sql string,
+	arguments ...any,
+) (pgconn.CommandTag, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.conn.Exec(ctx, sql, arguments...)
+}
+
+// Close closes the underlying connection, if closable, once any in-process
+// operation completes.
+func (sc *SyncConn) Close(ctx context.Context) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if closer, ok := sc.conn.(interface{ Close(context.Context) error }); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+func (sc *SyncConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	sc.mu.Lock()
+	rows, err := sc.conn.Query(ctx, sql, args...)
+	if rows == nil {
+		sc.mu.Unlock()
+		return rows, err
+	}
+	return &syncRows{Rows: rows, unlock: sc.unlocker()}, err
+}
+
+func (sc *SyncConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	rows, err := sc.conn.Query(ctx, sql, args...)
+	if err != nil {
+		if rows != nil {
+			rows.Close()
+		}
+		return &bufferedRow{err: err}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err = rows.Err()
+		if err == nil {
+			err = pgx.ErrNoRows
+		}
+		return &bufferedRow{err: err}
+	}
+
+	// the raw values are only valid until the rows are closed
+	values := make([][]byte, len(rows.RawValues()))
+	for i, value := range rows.RawValues() {
+		if value != nil {
+			values[i] = append([]byte{}, value...)
+		}
+	}
+
+	typeMap := pgtype.NewMap()
+	if conn := rows.Conn(); conn != nil {
+		typeMap = conn.TypeMap()
+	}
+
+	row := &bufferedRow{
+		typeMap: typeMap,
+		fields:  append([]pgconn.FieldDescription{}, rows.FieldDescriptions()...),
+		values:  values,
+	}
+
+	rows.Close()
+	row.err = rows.Err()
+	return row
+}
+
+func (sc *SyncConn) unlocker() func() {
+	var once sync.Once
+	return func() {
+		once.Do(sc.mu.Unlock)
+	}
+}
+
+type syncRows struct {
+	pgx.Rows
+	unlock func()
+}
+
+func (sr *syncRows) Close() {
+	sr.Rows.Close()
+	sr.unlock()
+}
+
+type syncTx struct {
+	pgx.Tx
+	unlock func()
+}
+
+func (st *syncTx) Commit(ctx context.Context) error {
+	defer st.unlock()
+	return st.Tx.Commit(ctx)
+}
+
+func (st *syncTx) Rollback(ctx context.Context) error {
+	defer st.unlock()
+	return st.Tx.Rollback(ctx)
+}
+
+// bufferedRow is a row already read from the connection, so scanning it
+// needs no access to the connection
+type bufferedRow struct {
+	typeMap *pgtype.Map
+	fields  []pgconn.FieldDescription
+	values  [][]byte
+	err     error
+}
+
+func (br *bufferedRow) Scan(dest ...any) error {
+	if br.err != nil {
+		return br.err
+	}
+	return pgx.ScanRow(br.typeMap, br.fields, br.values, dest...)
+}
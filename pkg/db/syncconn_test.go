@@ -0,0 +1,101 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	. "github.com/element84/swoop-go/pkg/db"
+)
+
+// fakeConn answers every query with the given integer rows
+type fakeConn struct {
+	values []string
+}
+
+func (fc *fakeConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (fc *fakeConn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (fc *fakeConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return &fakeRows{values: fc.values, idx: -1}, nil
+}
+
+func (fc *fakeConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	panic("not implemented")
+}
+
+type fakeRows struct {
+	values []string
+	idx    int
+}
+
+func (fr *fakeRows) Close()                        {}
+func (fr *fakeRows) Err() error                    { return nil }
+func (fr *fakeRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+func (fr *fakeRows) Scan(dest ...any) error        { return errors.New("not implemented") }
+func (fr *fakeRows) Values() ([]any, error)        { return nil, errors.New("not implemented") }
+func (fr *fakeRows) Conn() *pgx.Conn               { return nil }
+
+func (fr *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	return []pgconn.FieldDescription{{
+		Name:        "value",
+		DataTypeOID: pgtype.Int4OID,
+		Format:      pgtype.TextFormatCode,
+	}}
+}
+
+func (fr *fakeRows) Next() bool {
+	fr.idx++
+	return fr.idx < len(fr.values)
+}
+
+func (fr *fakeRows) RawValues() [][]byte {
+	return [][]byte{[]byte(fr.values[fr.idx])}
+}
+
+func Test_SyncConnQueryRow(t *testing.T) {
+	ctx := context.Background()
+	sc := NewSyncConn(&fakeConn{values: []string{"42"}})
+
+	// a row that is never scanned must not hold the connection
+	_ = sc.QueryRow(ctx, "SELECT 42")
+
+	done := make(chan error, 1)
+	go func() {
+		var value int
+		err := sc.QueryRow(ctx, "SELECT 42").Scan(&value)
+		if err == nil && value != 42 {
+			err = errors.New("unexpected value")
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("failed to scan row: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out querying after an unscanned row")
+	}
+}
+
+func Test_SyncConnQueryRowNoRows(t *testing.T) {
+	sc := NewSyncConn(&fakeConn{})
+
+	var value int
+	err := sc.QueryRow(context.Background(), "SELECT 42").Scan(&value)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected no rows error, got %v", err)
+	}
+}
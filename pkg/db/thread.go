@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/jackc/pgx/v5"
@@ -15,6 +16,8 @@ type Thread struct {
 	HandlerName string
 	Priority    int
 	LockId      int
+	// number of times the action has been retried, per its backoff events
+	Retries int
 }
 
 func GetProcessableThreads(
@@ -32,7 +35,12 @@ func GetProcessableThreads(
 			t.action_uuid as uuid,
 			t.handler_name as handlername,
 			t.priority as priority,
-			t.lock_id as lockid
+			t.lock_id as lockid,
+			(
+				SELECT count(*)
+				FROM swoop.event as e
+				WHERE e.action_uuid = t.action_uuid AND e.status = 'BACKOFF'
+			) as retries
 		FROM swoop.get_processable_actions(
 			_ignored_action_uuids => $1,
 			_handler_names => $2,
@@ -103,3 +111,41 @@ func (t *Thread) InsertBackoffEvent(
 		RetrySeconds: retrySeconds,
 	}).Insert(ctx, conn)
 }
+
+// LockThreadStatus returns the handler name and status of the action's
+// thread, locking the thread row until the end of the transaction.
+func LockThreadStatus(
+	ctx context.Context,
+	conn Conn,
+	actionUuid uuid.UUID,
+) (string, states.ActionState, error) {
+	var (
+		handlerName string
+		status      states.ActionState
+	)
+	err := conn.QueryRow(
+		ctx,
+		`SELECT handler_name, status
+		FROM swoop.thread
+		WHERE action_uuid = $1
+		FOR UPDATE`,
+		actionUuid,
+	).Scan(&handlerName, &status)
+	return handlerName, status, err
+}
+
+// GetRetryTimes returns the times at which any backed-off threads
+// for the given handler will become processable again.
+func GetRetryTimes(ctx context.Context, conn Conn, handlerName string) ([]time.Time, error) {
+	rows, _ := conn.Query(
+		ctx,
+		`SELECT next_attempt_after
+		FROM swoop.thread
+		WHERE
+		  handler_name = $1
+		  AND status = 'BACKOFF'
+		  AND next_attempt_after > now()`,
+		handlerName,
+	)
+	return pgx.CollectRows(rows, pgx.RowTo[time.Time])
+}
@@ -2,7 +2,10 @@ package errors
 
 type RequestError struct {
 	Retryable bool
-	Err       error
+	// RetryAfter, if set, is the seconds to wait before retrying,
+	// overriding the handler backoff
+	RetryAfter int
+	Err        error
 }
 
 func NewRequestError(err error, retryable bool) *RequestError {
@@ -15,3 +18,7 @@ func NewRequestError(err error, retryable bool) *RequestError {
 func (re *RequestError) Error() string {
 	return re.Err.Error()
 }
+
+func (re *RequestError) Unwrap() error {
+	return re.Err
+}
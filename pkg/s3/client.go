@@ -16,6 +16,8 @@ type minioClient interface {
 	RemoveBucketWithOptions(ctx context.Context, bucketName string, opts minio.RemoveBucketOptions) error
 	PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, opts minio.PutObjectOptions) (info minio.UploadInfo, err error)
 	GetObject(ctx context.Context, bucketName, objectName string, opts minio.GetObjectOptions) (*minio.Object, error)
+	ListObjects(ctx context.Context, bucketName string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo
+	RemoveObject(ctx context.Context, bucketName, objectName string, opts minio.RemoveObjectOptions) error
 	SetBucketEncryption(ctx context.Context, bucketname string, config *sse.Configuration) error
 }
 
@@ -63,6 +65,31 @@ func (s3 *s3client) PutStream(
 	return err
 }
 
+func (s3 *s3client) ListKeys(prefix string) ([]string, error) {
+	keys := []string{}
+	for obj := range s3.minioClient.ListObjects(
+		s3.context,
+		s3.Bucket,
+		minio.ListObjectsOptions{Prefix: prefix, Recursive: true},
+	) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+
+	return keys, nil
+}
+
+func (s3 *s3client) RemoveObject(key string) error {
+	return s3.minioClient.RemoveObject(
+		s3.context,
+		s3.Bucket,
+		key,
+		minio.RemoveObjectOptions{},
+	)
+}
+
 func (s3 *s3client) BucketExists() (bool, error) {
 	return s3.minioClient.BucketExists(
 		s3.context,
@@ -2,8 +2,11 @@ package s3
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 
@@ -139,7 +142,7 @@ func (d *S3Driver) newS3Client(ctx context.Context) (*s3client, error) {
 func (d *S3Driver) CheckConnect(ctx context.Context) error {
 	s3, err := d.newS3Client(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create new S3 client: %v", err)
+		return &clientError{err}
 	}
 
 	_, err = s3.BucketExists()
@@ -150,12 +153,55 @@ func (d *S3Driver) Get(ctx context.Context, key string) (*minio.Object, error) {
 	// TODO: retry transient errors? Or rely on higher-level retry maybe?
 	s3, err := d.newS3Client(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new S3 client: %v", err)
+		return nil, &clientError{err}
 	}
 
 	return s3.GetStream(key)
 }
 
+// IsNotFound is true if the error is due to a missing object
+func IsNotFound(err error) bool {
+	return minio.ToErrorResponse(err).Code == "NoSuchKey"
+}
+
+// clientError is a failure to create a client, usually because getting
+// credentials failed
+type clientError struct {
+	err error
+}
+
+func (ce *clientError) Error() string {
+	return fmt.Sprintf("failed to create new S3 client: %v", ce.err)
+}
+
+func (ce *clientError) Unwrap() error {
+	return ce.err
+}
+
+// IsRetryable is true if the error may be transient: a failure to get
+// credentials or to reach object storage, or a server error or throttling
+// response from it. Other errors, such as a missing object, denied access,
+// or an object that is not valid json, will not go away with a retry.
+func IsRetryable(err error) bool {
+	var ce *clientError
+	if errors.As(err, &ce) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	resp := minio.ToErrorResponse(err)
+	switch resp.Code {
+	case "InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout":
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError ||
+		resp.StatusCode == http.StatusTooManyRequests
+}
+
 type PutOptions minio.PutObjectOptions
 
 func (po PutOptions) ToMinioOpts() minio.PutObjectOptions {
@@ -172,7 +218,7 @@ func (d *S3Driver) Put(
 	// TODO: retry transient errors? Or rely on higher-level retry maybe?
 	s3, err := d.newS3Client(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create new S3 client: %v", err)
+		return &clientError{err}
 	}
 
 	if opts == nil {
@@ -182,10 +228,30 @@ func (d *S3Driver) Put(
 	return s3.PutStream(key, stream, length, opts.ToMinioOpts())
 }
 
+// List returns the keys of all objects with the prefix
+func (d *S3Driver) List(ctx context.Context, prefix string) ([]string, error) {
+	s3, err := d.newS3Client(ctx)
+	if err != nil {
+		return nil, &clientError{err}
+	}
+
+	return s3.ListKeys(prefix)
+}
+
+// Remove deletes the object; removing a missing object is not an error
+func (d *S3Driver) Remove(ctx context.Context, key string) error {
+	s3, err := d.newS3Client(ctx)
+	if err != nil {
+		return &clientError{err}
+	}
+
+	return s3.RemoveObject(key)
+}
+
 func (d *S3Driver) MakeBucket(ctx context.Context) error {
 	s3, err := d.newS3Client(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create new S3 client: %v", err)
+		return &clientError{err}
 	}
 
 	return s3.MakeBucket()
@@ -194,7 +260,7 @@ func (d *S3Driver) MakeBucket(ctx context.Context) error {
 func (d *S3Driver) RemoveBucket(ctx context.Context) error {
 	s3, err := d.newS3Client(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create new S3 client: %v", err)
+		return &clientError{err}
 	}
 
 	return s3.removeBucket()
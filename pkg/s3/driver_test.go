@@ -52,3 +52,41 @@ func TestDriver(t *testing.T) {
 		t.Fatalf("File contents are not equal: '%s' vs '%s'", content, testContent)
 	}
 }
+
+func TestDriverListRemove(t *testing.T) {
+	ctx := context.Background()
+
+	t3 := testS3.NewTestingS3(t, "testing-s3-")
+	t3.SetupBucket(ctx)
+
+	driver := t3.Driver
+
+	for _, key := range []string{"prefix/a", "prefix/b", "other/c"} {
+		reader := bytes.NewReader([]byte(key))
+		err := driver.Put(ctx, key, reader, int64(reader.Len()), nil)
+		if err != nil {
+			t.Fatalf("failed to put '%s': %s", key, err)
+		}
+	}
+
+	keys, err := driver.List(ctx, "prefix/")
+	if err != nil {
+		t.Fatalf("failed to list: %s", err)
+	}
+	if len(keys) != 2 || keys[0] != "prefix/a" || keys[1] != "prefix/b" {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	err = driver.Remove(ctx, "prefix/a")
+	if err != nil {
+		t.Fatalf("failed to remove: %s", err)
+	}
+
+	keys, err = driver.List(ctx, "prefix/")
+	if err != nil {
+		t.Fatalf("failed to list: %s", err)
+	}
+	if len(keys) != 1 || keys[0] != "prefix/b" {
+		t.Fatalf("unexpected keys after remove: %v", keys)
+	}
+}
@@ -61,3 +61,11 @@ func (s *JsonClient) PutJsonIntoObject(ctx context.Context, key string, j any) e
 
 	return nil
 }
+
+func (s *JsonClient) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	return s.driver.List(ctx, prefix)
+}
+
+func (s *JsonClient) RemoveObject(ctx context.Context, key string) error {
+	return s.driver.Remove(ctx, key)
+}
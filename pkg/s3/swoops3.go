@@ -3,6 +3,7 @@ package s3
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/gofrs/uuid/v5"
 )
@@ -44,3 +45,48 @@ func (s *SwoopS3) PutCallbackHttp(ctx context.Context, callbackUuid uuid.UUID, j
 	key := fmt.Sprintf("callbacks/%s/http.json", callbackUuid)
 	return s.jsonClient.PutJsonIntoObject(ctx, key, json)
 }
+
+func (s *SwoopS3) PutCallbackOutput(ctx context.Context, callbackUuid uuid.UUID, json any) error {
+	key := fmt.Sprintf("callbacks/%s/output.json", callbackUuid)
+	return s.jsonClient.PutJsonIntoObject(ctx, key, json)
+}
+
+// dead letters are kept under their own prefix, so they can be listed
+const deadLetterPrefix = "dead-letters/"
+
+func deadLetterKey(workflowUuid uuid.UUID) string {
+	return fmt.Sprintf("%s%s.json", deadLetterPrefix, workflowUuid)
+}
+
+func (s *SwoopS3) PutDeadLetter(ctx context.Context, workflowUuid uuid.UUID, json any) error {
+	return s.jsonClient.PutJsonIntoObject(ctx, deadLetterKey(workflowUuid), json)
+}
+
+func (s *SwoopS3) GetDeadLetter(ctx context.Context, workflowUuid uuid.UUID) (any, error) {
+	return s.jsonClient.GetJsonFromObject(ctx, deadLetterKey(workflowUuid))
+}
+
+func (s *SwoopS3) DeleteDeadLetter(ctx context.Context, workflowUuid uuid.UUID) error {
+	return s.jsonClient.RemoveObject(ctx, deadLetterKey(workflowUuid))
+}
+
+// ListDeadLetters returns the uuids of the workflows with dead letters
+func (s *SwoopS3) ListDeadLetters(ctx context.Context) ([]uuid.UUID, error) {
+	keys, err := s.jsonClient.ListKeys(ctx, deadLetterPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowUuids := make([]uuid.UUID, 0, len(keys))
+	for _, key := range keys {
+		name := strings.TrimSuffix(strings.TrimPrefix(key, deadLetterPrefix), ".json")
+		workflowUuid, err := uuid.FromString(name)
+		if err != nil {
+			// not one of ours, so we leave it be
+			continue
+		}
+		workflowUuids = append(workflowUuids, workflowUuid)
+	}
+
+	return workflowUuids, nil
+}
@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+type k8sSource struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	key       string
+}
+
+func newK8sSource(conf *config.K8sSecretConf) (*k8sSource, error) {
+	clientConfig := conf.K8sOptions.GetConfig()
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := conf.Namespace
+	if namespace == "" {
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &k8sSource{client, namespace, conf.Name, conf.Key}, nil
+}
+
+func (ks *k8sSource) Read(ctx context.Context) (string, error) {
+	secret, err := ks.client.CoreV1().Secrets(ks.namespace).Get(ctx, ks.name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := secret.Data[ks.key]
+	if !ok {
+		return "", fmt.Errorf("k8s secret '%s/%s' has no key '%s'", ks.namespace, ks.name, ks.key)
+	}
+
+	return string(val), nil
+}
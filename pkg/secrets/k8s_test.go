@@ -0,0 +1,38 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_K8sSource(t *testing.T) {
+	ctx := context.Background()
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "swoop", Name: "creds"},
+		Data:       map[string][]byte{"password": []byte("a-password")},
+	})
+
+	source := &k8sSource{client, "swoop", "creds", "password"}
+	val, err := source.Read(ctx)
+	if err != nil {
+		t.Fatalf("failed to read secret: %s", err)
+	}
+	if val != "a-password" {
+		t.Fatalf("unexpected secret value: '%s'", val)
+	}
+
+	_, err = (&k8sSource{client, "swoop", "creds", "user"}).Read(ctx)
+	if err == nil {
+		t.Fatal("missing key should error")
+	}
+
+	_, err = (&k8sSource{client, "other", "creds", "password"}).Read(ctx)
+	if err == nil {
+		t.Fatal("missing secret should error")
+	}
+}
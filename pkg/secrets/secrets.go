@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/element84/swoop-go/pkg/config"
+	"github.com/element84/swoop-go/pkg/utils"
+)
+
+// first delay before re-reading a secret that failed to refresh,
+// which doubles with each failure up to the secret's ttl
+const minRetry = 1 * time.Second
+
+// Secret holds the value of a secret, which is refreshed from its source
+// when read after its ttl expires. If a refresh fails then the last good
+// value continues to be used and the refresh is retried with backoff.
+type Secret struct {
+	name   string
+	source Source
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	value    string
+	expires  time.Time
+	failures int
+}
+
+func newSecret(name string, source Source, ttl time.Duration) *Secret {
+	return &Secret{
+		name:   name,
+		source: source,
+		ttl:    ttl,
+	}
+}
+
+// resolve reads the initial value, which unlike refreshes must succeed
+func (s *Secret) resolve(ctx context.Context) error {
+	value, err := s.source.Read(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve secret '%s': %s", s.name, err)
+	}
+
+	s.value = value
+	s.expires = time.Now().Add(s.ttl)
+	return nil
+}
+
+func (s *Secret) Value(ctx context.Context) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ttl <= 0 || time.Now().Before(s.expires) {
+		return s.value
+	}
+
+	value, err := s.source.Read(ctx)
+	if err != nil {
+		retry := time.Duration(utils.IntPow(2, min(s.failures, 16))) * minRetry
+		retry = min(retry, s.ttl)
+		s.failures++
+		s.expires = time.Now().Add(retry)
+		log.Printf(
+			"failed to refresh secret '%s', using last value and retrying in %s: %s",
+			s.name,
+			retry,
+			err,
+		)
+		return s.value
+	}
+
+	s.value = value
+	s.failures = 0
+	s.expires = time.Now().Add(s.ttl)
+	return s.value
+}
+
+// Secrets are the resolved secrets for a handler, by name
+type Secrets map[string]*Secret
+
+func New(ctx context.Context, confs []*config.HandlerSecret) (Secrets, error) {
+	secrets := make(Secrets, len(confs))
+	for _, conf := range confs {
+		if _, ok := secrets[conf.Name]; ok {
+			return nil, fmt.Errorf("duplicate secret name '%s'", conf.Name)
+		}
+
+		source, err := newSource(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		secret := newSecret(conf.Name, source, conf.GetTTL())
+		err = secret.resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets[conf.Name] = secret
+	}
+
+	return secrets, nil
+}
+
+// Data returns the current secret values, for use as template data
+func (ss Secrets) Data(ctx context.Context) map[string]string {
+	data := make(map[string]string, len(ss))
+	for name, secret := range ss {
+		data[name] = secret.Value(ctx)
+	}
+	return data
+}
@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+func Test_New(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "user")
+	err := os.WriteFile(path, []byte("a-user\n"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write secret file: %s", err)
+	}
+	t.Setenv("SWOOP_TEST_SECRET", "a-password")
+
+	secrets, err := New(ctx, []*config.HandlerSecret{
+		{Name: "user", Type: config.FileSecret, Path: path},
+		{Name: "password", Type: config.EnvSecret, Var: "SWOOP_TEST_SECRET"},
+	})
+	if err != nil {
+		t.Fatalf("failed to resolve secrets: %s", err)
+	}
+
+	data := secrets.Data(ctx)
+	if data["user"] != "a-user" || data["password"] != "a-password" {
+		t.Fatalf("unexpected secret values: %v", data)
+	}
+}
+
+func Test_NewUnresolvable(t *testing.T) {
+	_, err := New(context.Background(), []*config.HandlerSecret{
+		{Name: "missing", Type: config.EnvSecret, Var: "SWOOP_TEST_SECRET_UNSET"},
+	})
+	if err == nil {
+		t.Fatal("unresolvable secret should error at startup")
+	}
+}
+
+type testSource struct {
+	value string
+	err   error
+	reads int
+}
+
+func (ts *testSource) Read(ctx context.Context) (string, error) {
+	ts.reads++
+	return ts.value, ts.err
+}
+
+func Test_SecretRefresh(t *testing.T) {
+	ctx := context.Background()
+	ttl := 50 * time.Millisecond
+
+	source := &testSource{value: "first"}
+	secret := newSecret("test", source, ttl)
+	err := secret.resolve(ctx)
+	if err != nil {
+		t.Fatalf("failed to resolve secret: %s", err)
+	}
+
+	source.value = "second"
+	if val := secret.Value(ctx); val != "first" {
+		t.Fatalf("value should not refresh before ttl, got '%s'", val)
+	}
+
+	time.Sleep(ttl)
+	if val := secret.Value(ctx); val != "second" {
+		t.Fatalf("value should refresh after ttl, got '%s'", val)
+	}
+
+	time.Sleep(ttl)
+	source.value = ""
+	source.err = os.ErrNotExist
+	if val := secret.Value(ctx); val != "second" {
+		t.Fatalf("failed refresh should keep last value, got '%s'", val)
+	}
+
+	reads := source.reads
+	secret.Value(ctx)
+	if source.reads != reads {
+		t.Fatal("failed refresh should not be retried immediately")
+	}
+}
@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+// Source reads the current value of a secret
+type Source interface {
+	Read(ctx context.Context) (string, error)
+}
+
+type fileSource struct {
+	path string
+}
+
+func (fs *fileSource) Read(ctx context.Context) (string, error) {
+	b, err := os.ReadFile(fs.path)
+	if err != nil {
+		return "", err
+	}
+	// secret files commonly end with a newline that isn't part of the value
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+type envSource struct {
+	name string
+}
+
+func (es *envSource) Read(ctx context.Context) (string, error) {
+	val, ok := os.LookupEnv(es.name)
+	if !ok {
+		return "", fmt.Errorf("environment variable '%s' is not set", es.name)
+	}
+	return val, nil
+}
+
+func newSource(conf *config.HandlerSecret) (Source, error) {
+	switch conf.Type {
+	case config.FileSecret:
+		return &fileSource{conf.Path}, nil
+	case config.EnvSecret:
+		return &envSource{conf.Var}, nil
+	case config.K8sSecret:
+		return newK8sSource(conf.K8s)
+	case config.VaultSecret:
+		return newVaultSource(conf.Vault)
+	}
+	return nil, fmt.Errorf("unsupported secret type '%s'", conf.Type)
+}
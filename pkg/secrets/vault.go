@@ -0,0 +1,184 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+// vaultTimeout bounds each read from vault, including any login, as
+// readers of the secret wait on a refresh in progress
+var vaultTimeout = 10 * time.Second
+
+// vaultSource reads a key from a vault KV v2 secret using the http api
+type vaultSource struct {
+	client  *http.Client
+	address string
+	conf    *config.VaultSecretConf
+
+	mu    sync.Mutex
+	token string
+}
+
+func newVaultSource(conf *config.VaultSecretConf) (*vaultSource, error) {
+	address := conf.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, errors.New("vault secrets require an address or VAULT_ADDR")
+	}
+
+	return &vaultSource{
+		client:  &http.Client{Timeout: vaultTimeout},
+		address: strings.TrimSuffix(address, "/"),
+		conf:    conf,
+	}, nil
+}
+
+type vaultError struct {
+	status int
+	errors []string
+}
+
+func (ve *vaultError) Error() string {
+	return fmt.Sprintf("vault returned status %d: %s", ve.status, strings.Join(ve.errors, "; "))
+}
+
+func (vs *vaultSource) do(ctx context.Context, method, path, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, vs.address+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := vs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		e := struct {
+			Errors []string `json:"errors"`
+		}{}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return &vaultError{resp.StatusCode, e.Errors}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (vs *vaultSource) login(ctx context.Context) (string, error) {
+	auth := vs.conf.Auth
+
+	switch auth.Method {
+	case config.VaultTokenAuth:
+		token, ok := os.LookupEnv(auth.TokenVar)
+		if !ok {
+			return "", fmt.Errorf("environment variable '%s' is not set", auth.TokenVar)
+		}
+		return token, nil
+	case config.VaultK8sAuth:
+		jwt, err := os.ReadFile(auth.JwtPath)
+		if err != nil {
+			return "", err
+		}
+
+		resp := struct {
+			Auth struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}{}
+		err = vs.do(
+			ctx,
+			http.MethodPost,
+			fmt.Sprintf("auth/%s/login", auth.Mount),
+			"",
+			map[string]string{"jwt": strings.TrimSpace(string(jwt)), "role": auth.Role},
+			&resp,
+		)
+		if err != nil {
+			return "", fmt.Errorf("vault kubernetes login failed: %s", err)
+		}
+		return resp.Auth.ClientToken, nil
+	}
+
+	return "", fmt.Errorf("unsupported vault auth method '%s'", auth.Method)
+}
+
+func (vs *vaultSource) read(ctx context.Context, token string) (string, error) {
+	resp := struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}{}
+
+	path := fmt.Sprintf("%s/data/%s", vs.conf.Mount, strings.TrimPrefix(vs.conf.Path, "/"))
+	err := vs.do(ctx, http.MethodGet, (&url.URL{Path: path}).EscapedPath(), token, nil, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := resp.Data.Data[vs.conf.Key]
+	if !ok {
+		return "", fmt.Errorf("vault secret '%s' has no key '%s'", vs.conf.Path, vs.conf.Key)
+	}
+
+	s, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret '%s' key '%s' is not a string", vs.conf.Path, vs.conf.Key)
+	}
+
+	return s, nil
+}
+
+func (vs *vaultSource) Read(ctx context.Context) (string, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, vaultTimeout)
+	defer cancel()
+
+	// we reuse the token until vault rejects it, then login again
+	for attempt := 0; ; attempt++ {
+		if vs.token == "" {
+			token, err := vs.login(ctx)
+			if err != nil {
+				return "", err
+			}
+			vs.token = token
+		}
+
+		val, err := vs.read(ctx, vs.token)
+		var ve *vaultError
+		if attempt == 0 && errors.As(err, &ve) && ve.status == http.StatusForbidden {
+			vs.token = ""
+			continue
+		}
+
+		return val, err
+	}
+}
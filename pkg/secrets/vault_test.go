@@ -0,0 +1,184 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/element84/swoop-go/pkg/config"
+)
+
+// newVaultStandIn serves the subset of the vault api we use, issuing
+// tokens via kubernetes login that can be revoked to test re-login
+func newVaultStandIn(t *testing.T, jwt string, logins *int, revoked *bool) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["jwt"] != jwt || body["role"] != "swoop" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		*logins++
+		*revoked = false
+		json.NewEncoder(w).Encode(map[string]any{
+			"auth": map[string]any{"client_token": "a-token"},
+		})
+	})
+
+	mux.HandleFunc("/v1/secret/data/swoop/creds", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "a-token" || *revoked {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data": map[string]any{"password": "a-password"},
+			},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_VaultSourceK8sAuth(t *testing.T) {
+	ctx := context.Background()
+
+	jwtPath := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(jwtPath, []byte("a-jwt\n"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write jwt: %s", err)
+	}
+
+	var (
+		logins  int
+		revoked bool
+	)
+	server := newVaultStandIn(t, "a-jwt", &logins, &revoked)
+
+	conf := &config.VaultSecretConf{}
+	err = yaml.Unmarshal([]byte(`
+path: swoop/creds
+key: password
+auth:
+  method: kubernetes
+  role: swoop
+`), conf)
+	if err != nil {
+		t.Fatalf("failed to parse vault conf: %s", err)
+	}
+	conf.Address = server.URL
+	conf.Auth.JwtPath = jwtPath
+
+	source, err := newVaultSource(conf)
+	if err != nil {
+		t.Fatalf("failed to make vault source: %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		val, err := source.Read(ctx)
+		if err != nil {
+			t.Fatalf("failed to read secret: %s", err)
+		}
+		if val != "a-password" {
+			t.Fatalf("unexpected secret value: '%s'", val)
+		}
+	}
+	if logins != 1 {
+		t.Fatalf("token should be reused, got %d logins", logins)
+	}
+
+	revoked = true
+	_, err = source.Read(ctx)
+	if err != nil {
+		t.Fatalf("failed to read secret after token revoked: %s", err)
+	}
+	if logins != 2 {
+		t.Fatalf("expected re-login after token revoked, got %d logins", logins)
+	}
+}
+
+func Test_VaultSourceTokenAuth(t *testing.T) {
+	var (
+		logins  int
+		revoked bool
+	)
+	server := newVaultStandIn(t, "", &logins, &revoked)
+
+	t.Setenv("SWOOP_TEST_VAULT_TOKEN", "a-token")
+	source, err := newVaultSource(&config.VaultSecretConf{
+		Address: server.URL,
+		Mount:   "secret",
+		Path:    "swoop/creds",
+		Key:     "password",
+		Auth: config.VaultAuthConfig{
+			Method:   config.VaultTokenAuth,
+			TokenVar: "SWOOP_TEST_VAULT_TOKEN",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to make vault source: %s", err)
+	}
+
+	val, err := source.Read(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read secret: %s", err)
+	}
+	if val != "a-password" {
+		t.Fatalf("unexpected secret value: '%s'", val)
+	}
+
+	revoked = true
+	_, err = source.Read(context.Background())
+	if err == nil {
+		t.Fatal("revoked token should error")
+	}
+}
+
+func Test_VaultSourceTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { vaultTimeout = timeout }(vaultTimeout)
+	vaultTimeout = 100 * time.Millisecond
+
+	hung := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	t.Cleanup(server.Close)
+	// the handler must return before the server can close
+	t.Cleanup(func() { close(hung) })
+
+	t.Setenv("SWOOP_TEST_VAULT_TOKEN", "a-token")
+	source, err := newVaultSource(&config.VaultSecretConf{
+		Address: server.URL,
+		Mount:   "secret",
+		Path:    "swoop/creds",
+		Key:     "password",
+		Auth: config.VaultAuthConfig{
+			Method:   config.VaultTokenAuth,
+			TokenVar: "SWOOP_TEST_VAULT_TOKEN",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to make vault source: %s", err)
+	}
+
+	start := time.Now()
+	_, err = source.Read(context.Background())
+	if err == nil {
+		t.Fatal("read from hung vault should error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("read from hung vault should time out, took %s", elapsed)
+	}
+}
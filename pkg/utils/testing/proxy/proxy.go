@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Proxy forwards local TCP connections to postgres per the libpq
+// environment variables, allowing tests to cut open connections.
+type Proxy struct {
+	test     testing.TB
+	listener net.Listener
+	network  string
+	address  string
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func upstream() (string, string) {
+	host := os.Getenv("PGHOST")
+	port := os.Getenv("PGPORT")
+	if port == "" {
+		port = "5432"
+	}
+
+	if host == "" {
+		host = "localhost"
+	} else if strings.HasPrefix(host, "/") {
+		return "unix", host + "/.s.PGSQL." + port
+	}
+
+	return "tcp", net.JoinHostPort(host, port)
+}
+
+// NewPostgresProxy starts a proxy to postgres that is closed on test cleanup.
+func NewPostgresProxy(t testing.TB) *Proxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start proxy listener: %s", err)
+	}
+
+	network, address := upstream()
+	p := &Proxy{
+		test:     t,
+		listener: listener,
+		network:  network,
+		address:  address,
+	}
+	t.Cleanup(p.Close)
+
+	go p.serve()
+
+	return p
+}
+
+// Host and Port are suitable for use as PGHOST and PGPORT
+func (p *Proxy) Host() string {
+	host, _, _ := net.SplitHostPort(p.listener.Addr().String())
+	return host
+}
+
+func (p *Proxy) Port() string {
+	_, port, _ := net.SplitHostPort(p.listener.Addr().String())
+	return port
+}
+
+func (p *Proxy) serve() {
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			// listener closed
+			return
+		}
+
+		server, err := net.Dial(p.network, p.address)
+		if err != nil {
+			p.test.Logf("proxy failed to connect upstream: %s", err)
+			client.Close()
+			continue
+		}
+
+		p.mu.Lock()
+		p.conns = append(p.conns, client, server)
+		p.mu.Unlock()
+
+		go pipe(client, server)
+		go pipe(server, client)
+	}
+}
+
+func pipe(dst, src net.Conn) {
+	defer dst.Close()
+	defer src.Close()
+	_, _ = io.Copy(dst, src)
+}
+
+// Cut closes all open connections, while still accepting new ones.
+func (p *Proxy) Cut() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+func (p *Proxy) Close() {
+	p.listener.Close()
+	p.Cut()
+}